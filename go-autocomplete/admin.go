@@ -0,0 +1,55 @@
+package main
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strings"
+)
+
+const adminPathPrefix = "/admin/"
+
+// adminRoutes는 /admin/ 아래 관리 API입니다. 서비스 포트에서는 requireAdmin을 거치고, 디버그 포트(루프백)에는
+// 토큰 없이 붙습니다.
+func (s *server) adminRoutes() *http.ServeMux {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/admin/gc", s.handleGC)
+	mux.HandleFunc("/admin/keywords", s.handleAdminDelete)
+	mux.HandleFunc("/admin/stats", s.handleStats)
+	mux.HandleFunc("/admin/dashboard", s.handleDashboard)
+	mux.HandleFunc("/admin/bulk-mode", s.handleBulkMode)
+	mux.HandleFunc("/admin/reload", s.handleReload)
+	mux.HandleFunc("/admin/jobs/", s.handleJob)
+	if s.qlog != nil && s.cache != nil {
+		mux.HandleFunc("/admin/warm", s.handleWarm)
+	}
+	if s.cfg.SalesSourceURL != "" {
+		mux.HandleFunc("/admin/sales/sync", s.handleSalesSync)
+	}
+	if s.mir != nil {
+		mux.HandleFunc("/admin/mirror/dead-letters", s.handleDeadLetters)
+		mux.HandleFunc("/admin/mirror/replay", s.handleMirrorReplay)
+	}
+	if s.shadow != nil {
+		mux.HandleFunc("/admin/shadow", s.handleShadow)
+		mux.HandleFunc("/admin/shadow/rebuild", s.handleShadowRebuild)
+	}
+	if s.snap != nil {
+		mux.HandleFunc("/admin/snapshot", s.handleSnapshot)
+		mux.HandleFunc("/admin/restore", s.handleRestore)
+	}
+	return mux
+}
+
+// requireAdmin은 Authorization: Bearer <ADMIN_TOKEN>이 맞을 때만 next로 넘깁니다. 토큰이 설정되지 않았으면
+// 서비스 포트의 관리 API는 모두 거부합니다.
+func requireAdmin(token string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if token == "" || !ok || subtle.ConstantTimeCompare([]byte(got), []byte(token)) != 1 {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="admin"`)
+			writeError(w, r, codeUnauthorized)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
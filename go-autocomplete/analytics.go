@@ -0,0 +1,174 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/elastic/go-elasticsearch/v8/esapi"
+)
+
+// 한 번에 돌려주는 시간 버킷 수 상한입니다.
+const analyticsMaxBuckets = 2000
+
+type queryBucket struct {
+	Start          time.Time `json:"start"`
+	Queries        int64     `json:"queries"`
+	UniquePrefixes int64     `json:"unique_prefixes"`
+	ZeroResultRate float64   `json:"zero_result_rate"`
+}
+
+// esInterval은 fixed_interval 문자열로 바꿉니다. 분 단위 미만은 받지 않습니다.
+func esInterval(d time.Duration) string {
+	switch {
+	case d%(24*time.Hour) == 0:
+		return fmt.Sprintf("%dd", int64(d/(24*time.Hour)))
+	case d%time.Hour == 0:
+		return fmt.Sprintf("%dh", int64(d/time.Hour))
+	default:
+		return fmt.Sprintf("%dm", int64(d/time.Minute))
+	}
+}
+
+// queryHistogram은 쿼리 로그를 interval 단위로 묶어 요청 수, 고유 접두어 수(근사), 결과 없음 비율을 구합니다.
+// 결과 없음 비율의 분모는 ES를 조회한 요청(results가 있는 문서)입니다.
+func (s *store) queryHistogram(ctx context.Context, from, to time.Time, interval time.Duration, locale string) ([]queryBucket, error) {
+	filters := []interface{}{
+		map[string]interface{}{"range": map[string]interface{}{"@timestamp": map[string]interface{}{
+			"gte": from.Format(time.RFC3339), "lt": to.Format(time.RFC3339),
+		}}},
+	}
+	if locale != "" {
+		filters = append(filters, map[string]interface{}{"term": map[string]interface{}{"locale": locale}})
+	}
+	body, err := json.Marshal(map[string]interface{}{
+		"size":  0,
+		"query": map[string]interface{}{"bool": map[string]interface{}{"filter": filters}},
+		"aggs": map[string]interface{}{
+			"buckets": map[string]interface{}{
+				"date_histogram": map[string]interface{}{
+					"field":           "@timestamp",
+					"fixed_interval":  esInterval(interval),
+					"min_doc_count":   0,
+					"extended_bounds": map[string]interface{}{"min": from.UnixMilli(), "max": to.Add(-time.Millisecond).UnixMilli()},
+				},
+				"aggs": map[string]interface{}{
+					"unique_prefixes": map[string]interface{}{"cardinality": map[string]interface{}{"field": "prefix"}},
+					"answered":        map[string]interface{}{"filter": map[string]interface{}{"exists": map[string]interface{}{"field": "results"}}},
+					"zero":            map[string]interface{}{"filter": map[string]interface{}{"term": map[string]interface{}{"zero_result": true}}},
+				},
+			},
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("쿼리 직렬화 실패: %w", err)
+	}
+	res, err := esapi.SearchRequest{Index: []string{queryLogAlias}, Body: bytes.NewReader(body)}.Do(ctx, s.client)
+	if err != nil {
+		return nil, fmt.Errorf("검색 요청 실패: %w", err)
+	}
+	defer discard(res.Body)
+	if res.IsError() {
+		return nil, fmt.Errorf("검색 응답 에러: %s", res.String())
+	}
+
+	type count struct {
+		DocCount int64 `json:"doc_count"`
+	}
+	var parsed struct {
+		Aggregations struct {
+			Buckets struct {
+				Buckets []struct {
+					Key            int64 `json:"key"`
+					DocCount       int64 `json:"doc_count"`
+					UniquePrefixes struct {
+						Value int64 `json:"value"`
+					} `json:"unique_prefixes"`
+					Answered count `json:"answered"`
+					Zero     count `json:"zero"`
+				} `json:"buckets"`
+			} `json:"buckets"`
+		} `json:"aggregations"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&parsed); err != nil {
+		return nil, fmt.Errorf("응답 파싱 실패: %w", err)
+	}
+	out := make([]queryBucket, 0, len(parsed.Aggregations.Buckets.Buckets))
+	for _, b := range parsed.Aggregations.Buckets.Buckets {
+		bucket := queryBucket{
+			Start:          time.UnixMilli(b.Key).UTC(),
+			Queries:        b.DocCount,
+			UniquePrefixes: b.UniquePrefixes.Value,
+		}
+		if b.Answered.DocCount > 0 {
+			bucket.ZeroResultRate = float64(b.Zero.DocCount) / float64(b.Answered.DocCount)
+		}
+		out = append(out, bucket)
+	}
+	return out, nil
+}
+
+func (s *server) handleQueryAnalytics(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		methodNotAllowed(w, r, http.MethodGet)
+		return
+	}
+	params := r.URL.Query()
+	to := time.Now().UTC().Truncate(time.Minute)
+	if v := params.Get("to"); v != "" {
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			invalidParameter(w, r, "to")
+			return
+		}
+		to = t
+	}
+	from := to.Add(-24 * time.Hour)
+	if v := params.Get("from"); v != "" {
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil || !t.Before(to) {
+			invalidParameter(w, r, "from")
+			return
+		}
+		from = t
+	}
+	interval := time.Hour
+	if v := params.Get("interval"); v != "" {
+		d, err := parseAge(v)
+		if err != nil || d%time.Minute != 0 {
+			invalidParameter(w, r, "interval")
+			return
+		}
+		interval = d
+	}
+	// 기본 간격에도 적용해야 넓은 구간이 ES search.max_buckets를 넘겨 500이 되지 않습니다.
+	if to.Sub(from)/interval > analyticsMaxBuckets {
+		invalidParameter(w, r, "interval")
+		return
+	}
+	locale, ok := s.localeParam(w, r, params.Get("locale"))
+	if !ok {
+		return
+	}
+	if params.Get("locale") != "" {
+		locale = s.cfg.localeName(locale)
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), s.cfg.AdminTimeout)
+	defer cancel()
+	buckets, err := s.st.queryHistogram(ctx, from, to, interval, locale)
+	if err != nil {
+		logf(r.Context(), "쿼리 분석 집계 실패: %v", err)
+		writeError(w, r, codeSearchFailed)
+		return
+	}
+	writeJSON(w, map[string]interface{}{
+		"from":     from.UTC(),
+		"to":       to.UTC(),
+		"interval": esInterval(interval),
+		"buckets":  buckets,
+	})
+}
@@ -0,0 +1,151 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/elastic/go-elasticsearch/v8/esapi"
+)
+
+// 요청 하나로 지울 수 있는 최대 항목 수입니다.
+const batchDeleteMax = 1000
+
+type batchDeleteRequest struct {
+	Keywords []string `json:"keywords,omitempty"`
+	IDs      []string `json:"ids,omitempty"`
+	Locale   string   `json:"locale,omitempty"`
+}
+
+type batchDeleteItem struct {
+	Keyword string `json:"keyword,omitempty"`
+	ID      string `json:"id"`
+	Result  string `json:"result"`
+	Error   string `json:"error,omitempty"`
+}
+
+// deleteIDs는 문서 ID 목록을 Bulk delete 한 번으로 지우고 항목별 결과(deleted, not_found, error)를 돌려줍니다.
+func (s *store) deleteIDs(ctx context.Context, ids []string) ([]batchDeleteItem, error) {
+	var buf bytes.Buffer
+	for _, id := range ids {
+		line, err := json.Marshal(map[string]interface{}{"delete": map[string]interface{}{"_id": id}})
+		if err != nil {
+			return nil, fmt.Errorf("bulk 직렬화 실패: %w", err)
+		}
+		buf.Write(line)
+		buf.WriteByte('\n')
+	}
+	res, err := esapi.BulkRequest{Index: s.index, Body: &buf}.Do(ctx, s.client)
+	if err != nil {
+		return nil, fmt.Errorf("bulk 요청 실패: %w", err)
+	}
+	defer discard(res.Body)
+	if res.IsError() {
+		return nil, fmt.Errorf("bulk 응답 에러: %s", res.String())
+	}
+	var parsed struct {
+		Items []map[string]struct {
+			Result string          `json:"result"`
+			Error  json.RawMessage `json:"error"`
+		} `json:"items"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&parsed); err != nil {
+		return nil, fmt.Errorf("bulk 응답 파싱 실패: %w", err)
+	}
+	if len(parsed.Items) != len(ids) {
+		return nil, fmt.Errorf("bulk 응답 항목 수 불일치: %d/%d", len(parsed.Items), len(ids))
+	}
+	out := make([]batchDeleteItem, len(ids))
+	for i, item := range parsed.Items {
+		out[i].ID = ids[i]
+		for _, result := range item {
+			if len(result.Error) > 0 {
+				out[i].Result, out[i].Error = "error", string(result.Error)
+				continue
+			}
+			out[i].Result = result.Result
+		}
+	}
+	return out, nil
+}
+
+func (s *server) handleBatchDelete(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		methodNotAllowed(w, r, http.MethodPost)
+		return
+	}
+	var req batchDeleteRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, r, codeBadRequestBody)
+		return
+	}
+	total := len(req.Keywords) + len(req.IDs)
+	if total == 0 {
+		writeErrorDetails(w, r, codeBadRequestBody, map[string]interface{}{"required_one_of": []string{"keywords", "ids"}})
+		return
+	}
+	if total > batchDeleteMax {
+		writeErrorDetails(w, r, codeBadRequestBody, map[string]interface{}{"field": "keywords", "max": batchDeleteMax})
+		return
+	}
+	locale, ok := s.localeParam(w, r, req.Locale)
+	if !ok {
+		return
+	}
+
+	// 같은 문서를 가리키는 항목이 겹치면 bulk에서 두 번째가 not_found가 되므로 한 번만 보냅니다.
+	ids := make([]string, 0, total)
+	keywordOf := map[string]string{}
+	for i, kw := range req.Keywords {
+		kw = canonicalKeyword(kw)
+		if kw == "" {
+			writeErrorDetails(w, r, codeEmptyKeyword, map[string]string{"field": "keywords[" + strconv.Itoa(i) + "]"})
+			return
+		}
+		id := docID(kw)
+		if _, dup := keywordOf[id]; !dup {
+			ids = append(ids, id)
+		}
+		keywordOf[id] = kw
+	}
+	for _, id := range req.IDs {
+		if _, dup := keywordOf[id]; !dup && id != "" {
+			ids = append(ids, id)
+			keywordOf[id] = ""
+		}
+	}
+
+	st := s.st.withLocale(locale)
+	ctx, cancel := context.WithTimeout(r.Context(), s.cfg.AdminTimeout)
+	defer cancel()
+	items, err := s.removeIDs(ctx, st, ids)
+	if err != nil {
+		logf(r.Context(), "일괄 삭제 실패: %v", err)
+		writeError(w, r, codeDeleteFailed)
+		return
+	}
+
+	deleted := make([]string, 0, len(items))
+	var deletedKeywords []string
+	for i := range items {
+		items[i].Keyword = keywordOf[items[i].ID]
+		if items[i].Result == "deleted" {
+			deleted = append(deleted, items[i].ID)
+			// ID로만 지정한 항목은 키워드를 모르므로 목록에서 빠집니다.
+			if items[i].Keyword != "" && len(deletedKeywords) < webhookMaxKeywords {
+				deletedKeywords = append(deletedKeywords, items[i].Keyword)
+			}
+		}
+	}
+	if len(deleted) > 0 {
+		s.mir.enqueue("batch-delete", strconv.Itoa(len(deleted))+" docs", func(ctx context.Context, st *store) error {
+			_, err := s.removeIDs(ctx, st.withLocale(locale), deleted)
+			return err
+		})
+		s.hooks.publish(webhookEvent{Type: eventKeywordsDeleted, Reason: "batch", Locale: locale, Count: len(deleted), Keywords: deletedKeywords})
+	}
+	writeJSON(w, map[string]interface{}{"deleted": len(deleted), "items": items})
+}
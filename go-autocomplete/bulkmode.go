@@ -0,0 +1,193 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/elastic/go-elasticsearch/v8/esapi"
+)
+
+// indexTuning은 대량 적재 중에 바꿨다가 되돌리는 동적 인덱스 설정입니다.
+// RefreshInterval이 nil이면 클러스터 기본값으로 되돌립니다.
+type indexTuning struct {
+	RefreshInterval *string `json:"refresh_interval"`
+	Replicas        int     `json:"number_of_replicas"`
+}
+
+var bulkLoadTuning = func() indexTuning {
+	off := "-1"
+	return indexTuning{RefreshInterval: &off, Replicas: 0}
+}()
+
+func (s *store) currentTuning(ctx context.Context, index string) (indexTuning, error) {
+	flat := true
+	res, err := esapi.IndicesGetSettingsRequest{
+		Index:        []string{index},
+		Name:         []string{"index.refresh_interval", "index.number_of_replicas"},
+		FlatSettings: &flat,
+	}.Do(ctx, s.client)
+	if err != nil {
+		return indexTuning{}, fmt.Errorf("인덱스 설정 조회 실패: %w", err)
+	}
+	defer discard(res.Body)
+	if res.IsError() {
+		return indexTuning{}, fmt.Errorf("인덱스 설정 조회 응답 에러: %s", res.String())
+	}
+
+	var parsed map[string]struct {
+		Settings struct {
+			RefreshInterval *string `json:"index.refresh_interval"`
+			Replicas        int     `json:"index.number_of_replicas,string"`
+		} `json:"settings"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&parsed); err != nil {
+		return indexTuning{}, fmt.Errorf("응답 파싱 실패: %w", err)
+	}
+	for _, body := range parsed {
+		return indexTuning{RefreshInterval: body.Settings.RefreshInterval, Replicas: body.Settings.Replicas}, nil
+	}
+	return indexTuning{}, fmt.Errorf("인덱스 설정 없음: %s", index)
+}
+
+func (s *store) applyTuning(ctx context.Context, index string, t indexTuning) error {
+	body, err := json.Marshal(map[string]interface{}{"index": t})
+	if err != nil {
+		return fmt.Errorf("인덱스 설정 직렬화 실패: %w", err)
+	}
+	res, err := esapi.IndicesPutSettingsRequest{
+		Index: []string{index},
+		Body:  bytes.NewReader(body),
+	}.Do(ctx, s.client)
+	if err != nil {
+		return fmt.Errorf("인덱스 설정 변경 실패: %w", err)
+	}
+	defer discard(res.Body)
+	if res.IsError() {
+		return fmt.Errorf("인덱스 설정 변경 응답 에러: %s", res.String())
+	}
+	return nil
+}
+
+func (s *store) refreshIndex(ctx context.Context, index string) error {
+	res, err := esapi.IndicesRefreshRequest{Index: []string{index}}.Do(ctx, s.client)
+	if err != nil {
+		return fmt.Errorf("인덱스 refresh 실패: %w", err)
+	}
+	defer discard(res.Body)
+	if res.IsError() {
+		return fmt.Errorf("인덱스 refresh 응답 에러: %s", res.String())
+	}
+	return nil
+}
+
+// bulkMode는 POST /admin/bulk-mode로 켠 대량 적재 모드의 상태입니다. 모든 로케일 인덱스에 적용하며,
+// 켜기 전 인덱스별 설정을 기억해 두었다가 끌 때 되돌리고, 프로세스가 재시작되어 기억이 없으면
+// INDEX_REPLICAS와 기본 refresh_interval로 되돌립니다.
+type bulkMode struct {
+	mu       sync.Mutex
+	enabled  bool
+	since    time.Time
+	previous map[string]indexTuning
+}
+
+type bulkModeRequest struct {
+	Enabled *bool `json:"enabled" validate:"required"`
+}
+
+func (b *bulkMode) status() map[string]interface{} {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	out := map[string]interface{}{"enabled": b.enabled}
+	if b.enabled {
+		out["since"] = b.since.UTC()
+	}
+	return out
+}
+
+func (s *server) setBulkMode(ctx context.Context, enabled bool) error {
+	s.bulk.mu.Lock()
+	defer s.bulk.mu.Unlock()
+
+	if enabled {
+		if s.bulk.enabled {
+			return nil
+		}
+		prev := map[string]indexTuning{}
+		for _, locale := range s.cfg.locales() {
+			st := s.st.withLocale(locale)
+			t, err := st.currentTuning(ctx, st.index)
+			if err == nil {
+				err = st.applyTuning(ctx, st.index, bulkLoadTuning)
+			}
+			if err != nil {
+				// 일부 인덱스만 바뀐 채로 두지 않도록 이미 바꾼 인덱스는 되돌립니다.
+				for index, old := range prev {
+					if rerr := s.st.applyTuning(ctx, index, old); rerr != nil {
+						log.Printf("대량 적재 모드 되돌리기 실패 (%s): %v", index, rerr)
+					}
+				}
+				return err
+			}
+			prev[st.index] = t
+		}
+		s.bulk.enabled, s.bulk.since, s.bulk.previous = true, time.Now(), prev
+		log.Printf("대량 적재 모드 시작: refresh_interval=-1, number_of_replicas=0 (%d개 인덱스)", len(prev))
+		return nil
+	}
+
+	for _, locale := range s.cfg.locales() {
+		st := s.st.withLocale(locale)
+		restore, ok := s.bulk.previous[st.index]
+		if !ok {
+			restore = indexTuning{Replicas: st.settings.Replicas}
+		}
+		if err := st.applyTuning(ctx, st.index, restore); err != nil {
+			return err
+		}
+		if err := st.refreshIndex(ctx, st.index); err != nil {
+			return err
+		}
+	}
+	if s.bulk.enabled {
+		log.Printf("대량 적재 모드 종료 (%s 경과), 설정 복원 및 refresh 완료", time.Since(s.bulk.since).Round(time.Second))
+	}
+	s.bulk.enabled, s.bulk.previous = false, nil
+	return nil
+}
+
+func (s *server) handleBulkMode(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		writeJSON(w, s.bulk.status())
+		return
+	case http.MethodPost:
+	default:
+		methodNotAllowed(w, r, "GET, POST")
+		return
+	}
+
+	var req bulkModeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, r, codeBadRequestBody)
+		return
+	}
+	if req.Enabled == nil {
+		writeErrorDetails(w, r, codeBadRequestBody, map[string]string{"field": "enabled"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), s.cfg.AdminTimeout)
+	defer cancel()
+	if err := s.setBulkMode(ctx, *req.Enabled); err != nil {
+		logf(r.Context(), "대량 적재 모드 전환 실패: %v", err)
+		writeError(w, r, codeIndexSettingsFailed)
+		return
+	}
+	writeJSON(w, s.bulk.status())
+}
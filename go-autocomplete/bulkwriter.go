@@ -0,0 +1,253 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"expvar"
+	"fmt"
+	"log"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/elastic/go-elasticsearch/v8/esapi"
+)
+
+var (
+	bulkFlushes       = expvar.NewInt("bulk_flush_total")
+	bulkWriteFailures = expvar.NewInt("bulk_write_failures_total")
+
+	errWriteQueueFull = errors.New("쓰기 큐가 가득 참")
+)
+
+// bulkWriter는 업서트 요청을 제한된 큐에 쌓아 두고, 워커가 batchSize건 또는 flushEvery마다
+// Bulk API로 한꺼번에 반영합니다. 유입 폭증이 ES update 호출로 1:1 전달되지 않게 합니다.
+type bulkWriter struct {
+	st         *store
+	queue      chan upsertRequest
+	batchSize  int
+	flushEvery time.Duration
+	timeout    time.Duration
+	onWritten  func(req upsertRequest, created bool)
+
+	wg          sync.WaitGroup
+	lagMillis   atomic.Int64
+	lastFlushAt atomic.Int64
+}
+
+func newBulkWriter(st *store, queueSize, batchSize int, flushEvery, timeout time.Duration, onWritten func(req upsertRequest, created bool)) *bulkWriter {
+	return &bulkWriter{
+		st:         st,
+		queue:      make(chan upsertRequest, queueSize),
+		batchSize:  batchSize,
+		flushEvery: flushEvery,
+		timeout:    timeout,
+		onWritten:  onWritten,
+	}
+}
+
+func (bw *bulkWriter) start(ctx context.Context, workers int) {
+	for i := 0; i < workers; i++ {
+		bw.wg.Add(1)
+		go func() {
+			defer bw.wg.Done()
+			bw.work(ctx)
+		}()
+	}
+}
+
+// wait는 start에 넘긴 ctx가 취소된 뒤 워커가 큐를 비우고 끝날 때까지 기다립니다.
+func (bw *bulkWriter) wait() {
+	bw.wg.Wait()
+}
+
+func (bw *bulkWriter) enqueue(req upsertRequest) error {
+	select {
+	case bw.queue <- req:
+		return nil
+	default:
+		return errWriteQueueFull
+	}
+}
+
+func (bw *bulkWriter) work(ctx context.Context) {
+	ticker := time.NewTicker(bw.flushEvery)
+	defer ticker.Stop()
+	batch := make([]upsertRequest, 0, bw.batchSize)
+	var oldest time.Time
+	flush := func(ctx context.Context) {
+		if len(batch) == 0 {
+			return
+		}
+		bw.flush(ctx, batch)
+		bw.lagMillis.Store(time.Since(oldest).Milliseconds())
+		bw.lastFlushAt.Store(time.Now().UnixMilli())
+		batch = batch[:0]
+	}
+	add := func(ctx context.Context, req upsertRequest) {
+		if len(batch) == 0 {
+			oldest = time.Now()
+		}
+		batch = append(batch, req)
+		if len(batch) >= bw.batchSize {
+			flush(ctx)
+		}
+	}
+	for {
+		select {
+		case <-ctx.Done():
+			// 이미 202로 응답한 요청이므로 큐에 남은 것까지 반영하고 끝냅니다. 호출자는 더 이상 enqueue하지
+			// 않도록 HTTP 서버를 먼저 닫아야 합니다.
+			for {
+				select {
+				case req := <-bw.queue:
+					add(context.Background(), req)
+					continue
+				default:
+				}
+				break
+			}
+			flush(context.Background())
+			return
+		case req := <-bw.queue:
+			add(ctx, req)
+		case <-ticker.C:
+			flush(ctx)
+		}
+	}
+}
+
+// stats는 큐 적체와 마지막 flush 시점의 적재 지연(배치 내 가장 오래된 요청 기준)을 돌려줍니다.
+func (bw *bulkWriter) stats() map[string]interface{} {
+	out := map[string]interface{}{
+		"queue_depth":    len(bw.queue),
+		"queue_capacity": cap(bw.queue),
+		"lag_ms":         bw.lagMillis.Load(),
+	}
+	if ms := bw.lastFlushAt.Load(); ms > 0 {
+		out["last_flush_at"] = time.UnixMilli(ms).UTC()
+	}
+	return out
+}
+
+func (bw *bulkWriter) flush(ctx context.Context, batch []upsertRequest) {
+	if len(batch) == 0 {
+		return
+	}
+	bulkFlushes.Add(1)
+	ctx, cancel := context.WithTimeout(ctx, bw.timeout)
+	defer cancel()
+
+	// 가중치 증분은 조건부 업데이트가 필요하므로 bulk에 섞지 않고 개별 처리합니다.
+	plain := make([]upsertRequest, 0, len(batch))
+	for _, req := range batch {
+		if req.WeightDelta == 0 {
+			plain = append(plain, req)
+			continue
+		}
+		created, err := bw.st.withLocale(req.Locale).upsertKeyword(ctx, req)
+		if err != nil {
+			bulkWriteFailures.Add(1)
+			log.Printf("가중치 증분 실패 (%s): %v", req.Keyword, err)
+			continue
+		}
+		bw.written(req, created)
+	}
+	if len(plain) == 0 {
+		return
+	}
+
+	results, err := bw.st.bulkUpsert(ctx, plain)
+	if err != nil {
+		bulkWriteFailures.Add(int64(len(plain)))
+		log.Printf("bulk 업서트 실패 (%d건): %v", len(plain), err)
+		return
+	}
+	for i, req := range plain {
+		if msg := results[i].err; msg != "" {
+			bulkWriteFailures.Add(1)
+			log.Printf("bulk 업서트 항목 실패 (%s): %s", req.Keyword, msg)
+			continue
+		}
+		bw.written(req, results[i].created)
+	}
+}
+
+func (bw *bulkWriter) written(req upsertRequest, created bool) {
+	if bw.onWritten != nil {
+		bw.onWritten(req, created)
+	}
+}
+
+type bulkItemResult struct {
+	created bool
+	err     string
+}
+
+// bulkUpsert는 reqs를 하나의 Bulk 요청으로 업서트하고, reqs와 같은 순서로 항목별 결과를 돌려줍니다.
+func (s *store) bulkUpsert(ctx context.Context, reqs []upsertRequest) ([]bulkItemResult, error) {
+	var buf bytes.Buffer
+	for _, req := range reqs {
+		keyword := canonicalKeyword(req.Keyword)
+		weight := req.Weight
+		if weight == 0 {
+			weight = 1
+		}
+		if req.Meta == nil {
+			req.Meta = map[string]interface{}{}
+		}
+		action := map[string]interface{}{
+			"update": map[string]interface{}{
+				"_index":            s.withLocale(req.Locale).index,
+				"_id":               docID(keyword),
+				"retry_on_conflict": updateRetries,
+			},
+		}
+		payload := map[string]interface{}{
+			"doc":    keywordDoc(keyword, weight, req),
+			"upsert": s.withLocale(req.Locale).newKeywordDoc(keyword, weight, req),
+		}
+		for _, line := range []interface{}{action, payload} {
+			b, err := json.Marshal(line)
+			if err != nil {
+				return nil, fmt.Errorf("bulk 직렬화 실패: %w", err)
+			}
+			buf.Write(b)
+			buf.WriteByte('\n')
+		}
+	}
+
+	res, err := esapi.BulkRequest{Index: s.index, Body: &buf}.Do(ctx, s.client)
+	if err != nil {
+		return nil, fmt.Errorf("bulk 요청 실패: %w", err)
+	}
+	defer discard(res.Body)
+	if res.IsError() {
+		return nil, fmt.Errorf("bulk 응답 에러: %s", res.String())
+	}
+	var parsed struct {
+		Items []map[string]struct {
+			Result string          `json:"result"`
+			Error  json.RawMessage `json:"error"`
+		} `json:"items"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&parsed); err != nil {
+		return nil, fmt.Errorf("bulk 응답 파싱 실패: %w", err)
+	}
+	if len(parsed.Items) != len(reqs) {
+		return nil, fmt.Errorf("bulk 응답 항목 수 불일치: %d/%d", len(parsed.Items), len(reqs))
+	}
+	results := make([]bulkItemResult, len(reqs))
+	for i, item := range parsed.Items {
+		for _, result := range item {
+			if len(result.Error) > 0 {
+				results[i].err = string(result.Error)
+				continue
+			}
+			results[i].created = result.Result == "created"
+		}
+	}
+	return results, nil
+}
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"container/list"
+	"context"
+	"expvar"
+	"sync"
+	"time"
+)
+
+var suggestCacheTotal = expvar.NewMap("suggest_cache_total")
+
+// suggestCache는 접두어 suggester 결과를 파드 메모리에 LRU로 보관합니다. 항목에 조회 당시 로케일 generation을
+// 함께 두어 키워드가 바뀌면(generation이 바뀌면) 무효가 되고, generation이 없으면 TTL로만 만료됩니다.
+// TTL은 조회 때마다 받으므로 다시 읽은 설정이 이미 들어 있는 항목에도 적용됩니다.
+type suggestCache struct {
+	mu      sync.Mutex
+	max     int
+	entries map[string]*list.Element
+	lru     *list.List
+}
+
+type cacheEntry struct {
+	key       string
+	gen       string
+	items     []scoredSuggestion
+	corrected string
+	stored    time.Time
+}
+
+func newSuggestCache(max int, ttl time.Duration) *suggestCache {
+	if max <= 0 || ttl <= 0 {
+		return nil
+	}
+	return &suggestCache{max: max, entries: map[string]*list.Element{}, lru: list.New()}
+}
+
+// cacheKey에는 오타 보정 설정(fuzzy)도 넣어 설정을 다시 읽은 뒤 이전 보정 결과를 내주지 않게 합니다.
+func cacheKey(locale, channel, q, fuzzy string) string {
+	return locale + "\x00" + channel + "\x00" + matchKey(q) + "\x00" + fuzzy
+}
+
+func (c *suggestCache) get(key, gen string, now time.Time, ttl time.Duration) (*cacheEntry, bool) {
+	if c == nil {
+		return nil, false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	el, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	e := el.Value.(*cacheEntry)
+	if e.gen != gen || now.Sub(e.stored) >= ttl {
+		c.lru.Remove(el)
+		delete(c.entries, key)
+		return nil, false
+	}
+	c.lru.MoveToFront(el)
+	return e, true
+}
+
+func (c *suggestCache) put(key, gen string, items []scoredSuggestion, corrected string, now time.Time) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.max <= 0 {
+		return
+	}
+	if el, ok := c.entries[key]; ok {
+		el.Value = &cacheEntry{key: key, gen: gen, items: items, corrected: corrected, stored: now}
+		c.lru.MoveToFront(el)
+		return
+	}
+	c.entries[key] = c.lru.PushFront(&cacheEntry{key: key, gen: gen, items: items, corrected: corrected, stored: now})
+	c.evict()
+}
+
+// resize는 다시 읽은 SUGGEST_CACHE_SIZE를 적용합니다. 줄어들면 오래 안 쓴 항목부터 버리고, 0이면 캐시를 비운 채
+// 더 채우지 않습니다.
+func (c *suggestCache) resize(max int) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.max = max
+	c.evict()
+}
+
+func (c *suggestCache) evict() {
+	for c.lru.Len() > max(c.max, 0) {
+		oldest := c.lru.Back()
+		c.lru.Remove(oldest)
+		delete(c.entries, oldest.Value.(*cacheEntry).key)
+	}
+}
+
+func (c *suggestCache) size() int {
+	if c == nil {
+		return 0
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.lru.Len()
+}
+
+// suggestPrefix는 캐시에 있으면 그대로, 없으면 lookupPrefix로 조회해 성공한 결과를 캐시에 넣습니다.
+// 캐시된 목록은 여러 요청이 함께 읽으므로 호출자는 고치지 않아야 합니다.
+func (s *server) suggestPrefix(ctx context.Context, locale, channel, q string) ([]scoredSuggestion, string, error) {
+	if s.cache == nil {
+		return s.lookupPrefix(ctx, locale, channel, q)
+	}
+	tune := s.live()
+	key := cacheKey(locale, channel, q, tune.fuzzyKey())
+	gen, _ := s.gens.get(locale)
+	if e, ok := s.cache.get(key, gen, time.Now(), tune.CacheTTL); ok {
+		suggestCacheTotal.Add("hit", 1)
+		return e.items, e.corrected, nil
+	}
+	suggestCacheTotal.Add("miss", 1)
+	items, corrected, err := s.lookupPrefix(ctx, locale, channel, q)
+	if err != nil {
+		return nil, "", err
+	}
+	// 오타 보정 재조회가 실패해도 빈 결과가 오므로, 보정을 켠 상태의 빈 결과는 캐시하지 않습니다.
+	if len(items) > 0 || !tune.FuzzyRetry {
+		s.cache.put(key, gen, items, corrected, time.Now())
+	}
+	return items, corrected, nil
+}
@@ -0,0 +1,32 @@
+package main
+
+import (
+	"strings"
+
+	"golang.org/x/text/unicode/norm"
+	"golang.org/x/text/width"
+)
+
+// zeroWidth는 화면에 보이지 않지만 문자열 비교를 깨뜨리는 문자들입니다.
+var zeroWidth = strings.NewReplacer(
+	"\u200b", "", // zero width space
+	"\u200c", "", // zero width non-joiner
+	"\u200d", "", // zero width joiner
+	"\u2060", "", // word joiner
+	"\ufeff", "", // BOM
+)
+
+// canonicalKeyword는 저장과 docID 계산 전에 키워드를 한 가지 형태로 맞춥니다.
+// 전각/반각을 통일(ｉＰｈｏｎｅ → iPhone)하고 NFC로 정규화한 뒤,
+// 폭 없는 문자를 지우고 연속 공백을 한 칸으로 줄입니다. 대소문자는 유지합니다.
+func canonicalKeyword(s string) string {
+	s = width.Fold.String(s)
+	s = norm.NFC.String(s)
+	s = zeroWidth.Replace(s)
+	return strings.Join(strings.Fields(s), " ")
+}
+
+// matchKey는 문서 ID와 keyword 필드에 쓰는 비교용 형태입니다. 화면에 보여 줄 표기는 display 필드에 따로 둡니다.
+func matchKey(s string) string {
+	return strings.ToLower(canonicalKeyword(s))
+}
@@ -0,0 +1,177 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"expvar"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/elastic/go-elasticsearch/v8/esapi"
+)
+
+var (
+	cdcApplied = expvar.NewMap("cdc_events_total")
+	cdcSkipped = expvar.NewInt("cdc_events_skipped_total")
+)
+
+// cdcMapping은 products 테이블 컬럼을 키워드 문서 필드로 옮기는 규칙입니다.
+type cdcMapping struct {
+	KeywordField string
+	WeightField  string
+	MetaFields   []string
+}
+
+func (c config) cdcMapping() cdcMapping {
+	return cdcMapping{
+		KeywordField: c.CDCKeywordField,
+		WeightField:  c.CDCWeightField,
+		MetaFields:   c.CDCMetaFields,
+	}
+}
+
+// debeziumEvent는 Debezium 변경 이벤트입니다. JSON 컨버터의 schemas.enable 설정에 따라
+// payload 봉투가 있을 수도, 없을 수도 있습니다.
+type debeziumEvent struct {
+	Op     string                 `json:"op"`
+	Before map[string]interface{} `json:"before"`
+	After  map[string]interface{} `json:"after"`
+}
+
+func parseDebezium(value []byte) (debeziumEvent, error) {
+	var envelope struct {
+		Payload json.RawMessage `json:"payload"`
+	}
+	if err := json.Unmarshal(value, &envelope); err != nil {
+		return debeziumEvent{}, fmt.Errorf("이벤트 파싱 실패: %w", err)
+	}
+	if len(envelope.Payload) > 0 && !bytes.Equal(envelope.Payload, []byte("null")) {
+		value = envelope.Payload
+	}
+	var ev debeziumEvent
+	if err := json.Unmarshal(value, &ev); err != nil {
+		return debeziumEvent{}, fmt.Errorf("이벤트 파싱 실패: %w", err)
+	}
+	return ev, nil
+}
+
+func (m cdcMapping) keyword(row map[string]interface{}) string {
+	if row == nil {
+		return ""
+	}
+	v, _ := row[m.KeywordField].(string)
+	return v
+}
+
+func (m cdcMapping) upsert(row map[string]interface{}) upsertRequest {
+	req := upsertRequest{Keyword: m.keyword(row)}
+	if m.WeightField != "" {
+		switch w := row[m.WeightField].(type) {
+		case float64:
+			req.Weight = int(w)
+		case string:
+			// DECIMAL 컬럼은 Debezium이 문자열로 내보낼 수 있습니다.
+			if f, err := strconv.ParseFloat(w, 64); err == nil {
+				req.Weight = int(f)
+			}
+		}
+	}
+	if len(m.MetaFields) > 0 {
+		req.Meta = map[string]interface{}{}
+		for _, f := range m.MetaFields {
+			if v, ok := row[f]; ok && v != nil {
+				req.Meta[f] = v
+			}
+		}
+	}
+	return req
+}
+
+// deleteKeyword는 키워드 문서 하나를 지웁니다. 없으면 errKeywordNotFound입니다.
+func (s *store) deleteKeyword(ctx context.Context, keyword string) error {
+	res, err := esapi.DeleteRequest{Index: s.index, DocumentID: docID(keyword)}.Do(ctx, s.client)
+	if err != nil {
+		return fmt.Errorf("삭제 요청 실패: %w", err)
+	}
+	defer discard(res.Body)
+	if res.StatusCode == http.StatusNotFound {
+		return errKeywordNotFound
+	}
+	if res.IsError() {
+		return fmt.Errorf("삭제 응답 에러: %s", res.String())
+	}
+	return nil
+}
+
+// applyCDC는 Debezium 이벤트 하나를 반영합니다. 상품명이 바뀐 업데이트는 이전 키워드를 지우고 새 키워드를 씁니다.
+func (s *server) applyCDC(ctx context.Context, ev debeziumEvent) error {
+	m := s.cfg.cdcMapping()
+	switch ev.Op {
+	case "c", "r", "u":
+		req := m.upsert(ev.After)
+		cleaned, found := stripDisallowed(req.Keyword)
+		if cleaned == "" || (found && s.live().InputSanitize == sanitizeReject) {
+			cdcSkipped.Add(1)
+			return nil
+		}
+		req.Keyword = cleaned
+		if old := canonicalKeyword(m.keyword(ev.Before)); ev.Op == "u" && old != "" && matchKey(old) != matchKey(cleaned) {
+			if err := s.deleteCDC(ctx, old); err != nil {
+				return err
+			}
+		}
+		romanize := s.cfg.RomanizeKeywords
+		req.Romanize = &romanize
+		created, err := s.st.upsertKeyword(ctx, req)
+		if err != nil {
+			return err
+		}
+		s.upsertWritten(req, created)
+	case "d":
+		keyword := canonicalKeyword(m.keyword(ev.Before))
+		if keyword == "" {
+			cdcSkipped.Add(1)
+			return nil
+		}
+		return s.deleteCDC(ctx, keyword)
+	default:
+		cdcSkipped.Add(1)
+		return nil
+	}
+	cdcApplied.Add(ev.Op, 1)
+	return nil
+}
+
+func (s *server) deleteCDC(ctx context.Context, keyword string) error {
+	err := s.removeKeyword(ctx, s.st, keyword)
+	if errors.Is(err, errKeywordNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	cdcApplied.Add("d", 1)
+	s.mir.enqueue("delete", keyword, func(ctx context.Context, st *store) error {
+		if err := s.removeKeyword(ctx, st, keyword); err != nil && !errors.Is(err, errKeywordNotFound) {
+			return err
+		}
+		return nil
+	})
+	s.hooks.publish(webhookEvent{Type: eventKeywordsDeleted, Reason: "cdc", Keyword: keyword, Count: 1})
+	return nil
+}
+
+// handleCDC는 메시지 하나를 파싱해 반영합니다. 파싱할 수 없는 메시지는 errPoisonEvent로 감싸
+// 소스가 재시도하지 않게 합니다.
+func (s *server) handleCDC(ctx context.Context, value []byte) error {
+	ev, err := parseDebezium(value)
+	if err != nil {
+		return fmt.Errorf("%w: %v", errPoisonEvent, err)
+	}
+	applyCtx, cancel := context.WithTimeout(ctx, s.live().UpsertTimeout)
+	defer cancel()
+	return s.applyCDC(applyCtx, ev)
+}
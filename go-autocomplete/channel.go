@@ -0,0 +1,64 @@
+package main
+
+import (
+	"net/http"
+	"strings"
+)
+
+// channelAll은 채널을 지정하지 않은 키워드의 completion context 값입니다. context가 켜진 completion 필드는
+// 색인과 조회 모두 context가 있어야 하므로, 채널 구분이 없는 키워드는 모든 조회에 걸리도록 이 값으로 색인합니다.
+const channelAll = "all"
+
+// channelContexts는 suggest 조회에 넣을 channel context 값입니다. 채널을 지정하지 않으면 전체를 봅니다.
+func channelContexts(channels []string, channel string) []string {
+	if channel != "" {
+		return []string{channel, channelAll}
+	}
+	return append([]string{channelAll}, channels...)
+}
+
+func knownChannel(channels []string, channel string) bool {
+	for _, c := range channels {
+		if c == channel {
+			return true
+		}
+	}
+	return false
+}
+
+// channelParam은 /suggest의 channel 값을 확인합니다. SUGGEST_CHANNELS가 비어 있으면 채널 구분을 쓰지 않으므로
+// 어떤 값이든 400입니다.
+func (s *server) channelParam(w http.ResponseWriter, r *http.Request, raw string) (string, bool) {
+	channel := strings.ToLower(strings.TrimSpace(raw))
+	if channel == "" {
+		return "", true
+	}
+	if !knownChannel(s.cfg.Channels, channel) {
+		invalidParameter(w, r, "channel")
+		return "", false
+	}
+	return channel, true
+}
+
+// resolveChannels는 업서트 본문의 channels를 확인하고 소문자로 맞춥니다. 비어 있으면 기존 문서의 채널을 유지하고,
+// 새 문서는 newKeywordDoc이 channelAll로 색인합니다.
+func (s *server) resolveChannels(w http.ResponseWriter, r *http.Request, req *upsertRequest) bool {
+	if len(s.cfg.Channels) == 0 {
+		if len(req.Channels) > 0 {
+			writeErrorDetails(w, r, codeBadRequestBody, map[string]string{"field": "channels"})
+			return false
+		}
+		return true
+	}
+	var out []string
+	for _, c := range req.Channels {
+		c = strings.ToLower(strings.TrimSpace(c))
+		if c != channelAll && !knownChannel(s.cfg.Channels, c) {
+			writeErrorDetails(w, r, codeBadRequestBody, map[string]string{"field": "channels"})
+			return false
+		}
+		out = append(out, c)
+	}
+	req.Channels = out
+	return true
+}
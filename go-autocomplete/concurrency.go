@@ -0,0 +1,143 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/elastic/go-elasticsearch/v8/esapi"
+)
+
+const updateRetries = 5
+
+var errVersionConflict = errors.New("문서 버전 충돌")
+
+type versionedDoc struct {
+	SeqNo       int `json:"_seq_no"`
+	PrimaryTerm int `json:"_primary_term"`
+	Found       bool
+	Source      struct {
+		Weight  *int `json:"weight"`
+		Suggest struct {
+			Weight int `json:"weight"`
+		} `json:"suggest"`
+	} `json:"_source"`
+}
+
+func (d versionedDoc) weight() int {
+	if d.Source.Weight != nil {
+		return *d.Source.Weight
+	}
+	return d.Source.Suggest.Weight
+}
+
+// incrementWeight는 현재 가중치를 읽어 delta를 더한 뒤 if_seq_no/if_primary_term 조건부로 씁니다.
+// 피드백과 카탈로그 동기화가 동시에 가중치를 바꿔도 증분이 유실되지 않도록 충돌 시 다시 읽어 재시도합니다.
+func (s *store) incrementWeight(ctx context.Context, keyword string, req upsertRequest) (bool, error) {
+	id := docID(keyword)
+	for attempt := 0; attempt <= updateRetries; attempt++ {
+		if attempt > 0 {
+			select {
+			case <-ctx.Done():
+				return false, ctx.Err()
+			case <-time.After(time.Duration(attempt*attempt) * 10 * time.Millisecond):
+			}
+		}
+
+		current, err := s.getVersioned(ctx, id)
+		if err != nil {
+			return false, err
+		}
+		if !current.Found {
+			weight := req.WeightDelta
+			if req.Weight != 0 {
+				weight = req.Weight + req.WeightDelta
+			}
+			err = s.createDoc(ctx, id, s.newKeywordDoc(keyword, weight, req))
+		} else {
+			err = s.conditionalUpdate(ctx, id, current, keywordDoc(keyword, current.weight()+req.WeightDelta, req))
+		}
+		if errors.Is(err, errVersionConflict) {
+			continue
+		}
+		return !current.Found, err
+	}
+	return false, fmt.Errorf("가중치 증분 재시도 초과 (%s): %w", keyword, errVersionConflict)
+}
+
+func (s *store) getVersioned(ctx context.Context, id string) (versionedDoc, error) {
+	res, err := esapi.GetRequest{
+		Index:      s.index,
+		DocumentID: id,
+		Source:     []string{"weight", "suggest.weight"},
+	}.Do(ctx, s.client)
+	if err != nil {
+		return versionedDoc{}, fmt.Errorf("문서 조회 요청 실패: %w", err)
+	}
+	defer discard(res.Body)
+	if res.StatusCode == http.StatusNotFound {
+		return versionedDoc{}, nil
+	}
+	if res.IsError() {
+		return versionedDoc{}, fmt.Errorf("문서 조회 응답 에러: %s", res.String())
+	}
+	var doc versionedDoc
+	if err := json.NewDecoder(res.Body).Decode(&doc); err != nil {
+		return versionedDoc{}, fmt.Errorf("응답 파싱 실패: %w", err)
+	}
+	doc.Found = true
+	return doc, nil
+}
+
+func (s *store) createDoc(ctx context.Context, id string, doc map[string]interface{}) error {
+	body, err := json.Marshal(doc)
+	if err != nil {
+		return fmt.Errorf("payload 직렬화 실패: %w", err)
+	}
+	res, err := esapi.CreateRequest{
+		Index:      s.index,
+		DocumentID: id,
+		Body:       bytes.NewReader(body),
+	}.Do(ctx, s.client)
+	if err != nil {
+		return fmt.Errorf("문서 생성 요청 실패: %w", err)
+	}
+	defer discard(res.Body)
+	if res.StatusCode == http.StatusConflict {
+		return errVersionConflict
+	}
+	if res.IsError() {
+		return fmt.Errorf("문서 생성 응답 에러: %s", res.String())
+	}
+	return nil
+}
+
+func (s *store) conditionalUpdate(ctx context.Context, id string, current versionedDoc, doc map[string]interface{}) error {
+	body, err := json.Marshal(map[string]interface{}{"doc": doc})
+	if err != nil {
+		return fmt.Errorf("payload 직렬화 실패: %w", err)
+	}
+	seqNo, primaryTerm := current.SeqNo, current.PrimaryTerm
+	res, err := esapi.UpdateRequest{
+		Index:         s.index,
+		DocumentID:    id,
+		Body:          bytes.NewReader(body),
+		IfSeqNo:       &seqNo,
+		IfPrimaryTerm: &primaryTerm,
+	}.Do(ctx, s.client)
+	if err != nil {
+		return fmt.Errorf("조건부 업데이트 요청 실패: %w", err)
+	}
+	defer discard(res.Body)
+	if res.StatusCode == http.StatusConflict {
+		return errVersionConflict
+	}
+	if res.IsError() {
+		return fmt.Errorf("조건부 업데이트 응답 에러: %s", res.String())
+	}
+	return nil
+}
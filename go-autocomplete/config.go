@@ -0,0 +1,443 @@
+package main
+
+import (
+	"os"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+type config struct {
+	Backend      string
+	ESURL        string
+	ESUsername   string
+	ESPassword   string
+	Port         string
+	SigV4        bool
+	SigV4Region  string
+	SigV4Service string
+
+	SecondaryBackend  string
+	SecondaryURL      string
+	SecondaryUsername string
+	SecondaryPassword string
+	MirrorQueueSize   int
+	MirrorDeadLetters int
+
+	ReplicaBackend    string
+	ReplicaURLs       []string
+	ReplicaUsername   string
+	ReplicaPassword   string
+	FailoverThreshold time.Duration
+	FailoverCooldown  time.Duration
+
+	FallbackTopN    int
+	FallbackRefresh time.Duration
+	ETagInterval    time.Duration
+
+	CacheSize   int
+	CacheTTL    time.Duration
+	WarmTopN    int
+	WarmWindow  time.Duration
+	WarmTimeout time.Duration
+
+	ProductIndex       string
+	ProductNameField   string
+	ProductSuggestSize int
+
+	SnapshotBucket    string
+	SnapshotPrefix    string
+	SnapshotEndpoint  string
+	SnapshotPathStyle bool
+	SnapshotInterval  time.Duration
+	SnapshotRetain    int
+
+	IdempotencyTTL     time.Duration
+	IdempotencyMaxKeys int
+
+	AsyncWrites       bool
+	WriteQueueSize    int
+	BulkBatchSize     int
+	BulkFlushInterval time.Duration
+	BulkWorkers       int
+
+	MaxConcurrentReads  int
+	MaxConcurrentWrites int
+	ConcurrencyWait     time.Duration
+
+	SuggestTimeout time.Duration
+	UpsertTimeout  time.Duration
+	BulkTimeout    time.Duration
+	AdminTimeout   time.Duration
+	ReadTimeout    time.Duration
+	WriteTimeout   time.Duration
+	IdleTimeout    time.Duration
+
+	ShutdownTimeout time.Duration
+
+	DebugAddr  string
+	AdminToken string
+
+	ExpiryCleanupInterval time.Duration
+	SoftDeleteRetention   time.Duration
+
+	GCOlderThan time.Duration
+	GCInterval  time.Duration
+
+	IndexShards   int
+	IndexReplicas int
+	IndexMinGram  int
+	IndexMaxGram  int
+
+	ShadowIndex      string
+	ShadowSampleRate float64
+	ShadowTokenizer  string
+	ShadowMinGram    int
+	ShadowMaxGram    int
+
+	InputSanitize string
+
+	AnalyticsRolloverSize string
+	AnalyticsRolloverAge  time.Duration
+	AnalyticsRetention    time.Duration
+	QueryLog              bool
+	QueryLogQueueSize     int
+	QueryLogFlush         time.Duration
+
+	MinPrefixLength    int
+	MinPrefixLengthCJK int
+
+	FuzzyRetry bool
+	Fuzziness  string
+
+	RankWeight          float64
+	RankRecency         float64
+	RankClicks          float64
+	RankRecencyHalfLife time.Duration
+	RankClickHalfLife   time.Duration
+
+	EmbeddingURL      string
+	EmbeddingDims     int
+	EmbeddingTimeout  time.Duration
+	EmbeddingBatch    int
+	EmbeddingInterval time.Duration
+	SemanticMinScore  float64
+
+	SalesSourceURL    string
+	SalesInterval     time.Duration
+	SalesWindow       time.Duration
+	SalesFormula      string
+	SalesWeightBase   float64
+	SalesWeightFactor float64
+	SalesWeightMax    int
+
+	RomanizeKeywords bool
+
+	DefaultLocale string
+	ExtraLocales  []string
+
+	Channels []string
+
+	WebhookURLs      []string
+	WebhookSecret    string
+	WebhookQueueSize int
+	WebhookRetries   int
+	WebhookTimeout   time.Duration
+
+	CDCSource       string
+	CDCBrokers      []string
+	CDCTopic        string
+	CDCGroup        string
+	NATSURL         string
+	CDCStream       string
+	CDCSubject      string
+	CDCDurable      string
+	CDCKeywordField string
+	CDCWeightField  string
+	CDCMetaFields   []string
+}
+
+func loadConfig() config {
+	return config{
+		Backend:      strings.ToLower(envOr("SEARCH_BACKEND", backendElasticsearch)),
+		ESURL:        envOr("ELASTICSEARCH_URL", defaultESHost),
+		ESUsername:   os.Getenv("ELASTICSEARCH_USERNAME"),
+		ESPassword:   os.Getenv("ELASTICSEARCH_PASSWORD"),
+		Port:         envOr("PORT", "8080"),
+		SigV4:        envBool("ELASTICSEARCH_SIGV4", false),
+		SigV4Region:  strings.TrimSpace(os.Getenv("ELASTICSEARCH_SIGV4_REGION")),
+		SigV4Service: envOr("ELASTICSEARCH_SIGV4_SERVICE", "es"),
+
+		SecondaryBackend:  strings.ToLower(envOr("SECONDARY_SEARCH_BACKEND", envOr("SEARCH_BACKEND", backendElasticsearch))),
+		SecondaryURL:      strings.TrimSpace(os.Getenv("SECONDARY_ELASTICSEARCH_URL")),
+		SecondaryUsername: os.Getenv("SECONDARY_ELASTICSEARCH_USERNAME"),
+		SecondaryPassword: os.Getenv("SECONDARY_ELASTICSEARCH_PASSWORD"),
+		MirrorQueueSize:   envInt("MIRROR_QUEUE_SIZE", 1000),
+		MirrorDeadLetters: envInt("MIRROR_DEAD_LETTER_SIZE", 1000),
+
+		ReplicaBackend:    strings.ToLower(envOr("REPLICA_SEARCH_BACKEND", envOr("SEARCH_BACKEND", backendElasticsearch))),
+		ReplicaURLs:       envList("REPLICA_ELASTICSEARCH_URLS"),
+		ReplicaUsername:   os.Getenv("REPLICA_ELASTICSEARCH_USERNAME"),
+		ReplicaPassword:   os.Getenv("REPLICA_ELASTICSEARCH_PASSWORD"),
+		FailoverThreshold: envDuration("SUGGEST_FAILOVER_THRESHOLD", 300*time.Millisecond),
+		FailoverCooldown:  envDuration("SUGGEST_FAILOVER_COOLDOWN", 30*time.Second),
+
+		FallbackTopN:    envInt("FALLBACK_TOP_N", 5000),
+		FallbackRefresh: envDuration("FALLBACK_REFRESH_INTERVAL", 5*time.Minute),
+		ETagInterval:    envDuration("SUGGEST_ETAG_INTERVAL", 5*time.Second),
+
+		CacheSize:   envInt("SUGGEST_CACHE_SIZE", 10000),
+		CacheTTL:    envDuration("SUGGEST_CACHE_TTL", 30*time.Second),
+		WarmTopN:    envInt("SUGGEST_WARM_TOP_N", 1000),
+		WarmWindow:  envAge("SUGGEST_WARM_WINDOW", 7*24*time.Hour),
+		WarmTimeout: envDuration("SUGGEST_WARM_TIMEOUT", time.Minute),
+
+		ProductIndex:       strings.TrimSpace(os.Getenv("PRODUCT_INDEX")),
+		ProductNameField:   envOr("PRODUCT_NAME_FIELD", "name"),
+		ProductSuggestSize: envInt("PRODUCT_SUGGEST_SIZE", 4),
+
+		SnapshotBucket:    strings.TrimSpace(os.Getenv("SNAPSHOT_S3_BUCKET")),
+		SnapshotPrefix:    envOr("SNAPSHOT_S3_PREFIX", "autocomplete/"),
+		SnapshotEndpoint:  strings.TrimSpace(os.Getenv("SNAPSHOT_S3_ENDPOINT")),
+		SnapshotPathStyle: envBool("SNAPSHOT_S3_PATH_STYLE", false),
+		SnapshotInterval:  envDuration("SNAPSHOT_INTERVAL", 24*time.Hour),
+		SnapshotRetain:    envInt("SNAPSHOT_RETAIN", 7),
+
+		IdempotencyTTL:     envDuration("IDEMPOTENCY_TTL", 0),
+		IdempotencyMaxKeys: envInt("IDEMPOTENCY_MAX_KEYS", 100000),
+
+		AsyncWrites:       envBool("ASYNC_WRITES", false),
+		WriteQueueSize:    envInt("WRITE_QUEUE_SIZE", 10000),
+		BulkBatchSize:     envInt("BULK_BATCH_SIZE", 500),
+		BulkFlushInterval: envDuration("BULK_FLUSH_INTERVAL", 200*time.Millisecond),
+		BulkWorkers:       envInt("BULK_WORKERS", 2),
+
+		MaxConcurrentReads:  envInt("ES_MAX_CONCURRENT_READS", 64),
+		MaxConcurrentWrites: envInt("ES_MAX_CONCURRENT_WRITES", 32),
+		ConcurrencyWait:     envDuration("ES_CONCURRENCY_WAIT", 100*time.Millisecond),
+
+		SuggestTimeout: envDuration("SUGGEST_TIMEOUT", time.Second),
+		UpsertTimeout:  envDuration("UPSERT_TIMEOUT", 5*time.Second),
+		BulkTimeout:    envDuration("BULK_TIMEOUT", 30*time.Second),
+		AdminTimeout:   envDuration("ADMIN_TIMEOUT", 5*time.Minute),
+		ReadTimeout:    envDuration("HTTP_READ_TIMEOUT", 10*time.Second),
+		WriteTimeout:   envDuration("HTTP_WRITE_TIMEOUT", 6*time.Minute),
+		IdleTimeout:    envDuration("HTTP_IDLE_TIMEOUT", 2*time.Minute),
+
+		ShutdownTimeout: envDuration("SHUTDOWN_TIMEOUT", 25*time.Second),
+
+		DebugAddr:  envOr("DEBUG_ADDR", "127.0.0.1:6060"),
+		AdminToken: strings.TrimSpace(os.Getenv("ADMIN_TOKEN")),
+
+		ExpiryCleanupInterval: envDuration("EXPIRY_CLEANUP_INTERVAL", 10*time.Minute),
+		SoftDeleteRetention:   envAge("SOFT_DELETE_RETENTION", 0),
+
+		GCOlderThan: envAge("GC_OLDER_THAN", 0),
+		GCInterval:  envDuration("GC_INTERVAL", 24*time.Hour),
+
+		IndexShards:   envInt("INDEX_SHARDS", 1),
+		IndexReplicas: envInt("INDEX_REPLICAS", 1),
+		IndexMinGram:  envInt("INDEX_MIN_GRAM", 1),
+		IndexMaxGram:  envInt("INDEX_MAX_GRAM", 20),
+
+		ShadowIndex:      strings.TrimSpace(os.Getenv("SHADOW_INDEX")),
+		ShadowSampleRate: envFloat("SHADOW_SAMPLE_RATE", 0.05),
+		ShadowTokenizer:  strings.TrimSpace(os.Getenv("SHADOW_TOKENIZER")),
+		ShadowMinGram:    envInt("SHADOW_MIN_GRAM", 0),
+		ShadowMaxGram:    envInt("SHADOW_MAX_GRAM", 0),
+
+		InputSanitize: strings.ToLower(envOr("INPUT_SANITIZE", sanitizeStrip)),
+
+		AnalyticsRolloverSize: envOr("ANALYTICS_ROLLOVER_SIZE", "5gb"),
+		AnalyticsRolloverAge:  envAge("ANALYTICS_ROLLOVER_AGE", 24*time.Hour),
+		AnalyticsRetention:    envAge("ANALYTICS_RETENTION", 30*24*time.Hour),
+		QueryLog:              envBool("QUERY_LOG", false),
+		QueryLogQueueSize:     envInt("QUERY_LOG_QUEUE_SIZE", 10000),
+		QueryLogFlush:         envDuration("QUERY_LOG_FLUSH_INTERVAL", 5*time.Second),
+
+		MinPrefixLength:    envInt("SUGGEST_MIN_PREFIX", 1),
+		MinPrefixLengthCJK: envInt("SUGGEST_MIN_PREFIX_CJK", 1),
+
+		FuzzyRetry: envBool("SUGGEST_FUZZY_RETRY", false),
+		Fuzziness:  envOr("SUGGEST_FUZZINESS", "AUTO"),
+
+		RankWeight:          envFloat("RANK_WEIGHT", 1),
+		RankRecency:         envFloat("RANK_RECENCY", 0),
+		RankClicks:          envFloat("RANK_CLICKS", 0),
+		RankRecencyHalfLife: envAge("RANK_RECENCY_HALF_LIFE", 30*24*time.Hour),
+		RankClickHalfLife:   envAge("RANK_CLICK_HALF_LIFE", 7*24*time.Hour),
+
+		EmbeddingURL:      strings.TrimSpace(os.Getenv("EMBEDDING_URL")),
+		EmbeddingDims:     envInt("EMBEDDING_DIMS", 0),
+		EmbeddingTimeout:  envDuration("EMBEDDING_TIMEOUT", 2*time.Second),
+		EmbeddingBatch:    envInt("EMBEDDING_BATCH_SIZE", 64),
+		EmbeddingInterval: envDuration("EMBEDDING_BACKFILL_INTERVAL", time.Minute),
+		SemanticMinScore:  envFloat("SEMANTIC_MIN_SCORE", 0),
+
+		SalesSourceURL:    strings.TrimSpace(os.Getenv("SALES_SOURCE_URL")),
+		SalesInterval:     envDuration("SALES_SYNC_INTERVAL", time.Hour),
+		SalesWindow:       envAge("SALES_WINDOW", 30*24*time.Hour),
+		SalesFormula:      strings.ToLower(envOr("SALES_WEIGHT_FORMULA", salesFormulaLog)),
+		SalesWeightBase:   envFloat("SALES_WEIGHT_BASE", 1),
+		SalesWeightFactor: envFloat("SALES_WEIGHT_FACTOR", 10),
+		SalesWeightMax:    envInt("SALES_WEIGHT_MAX", 1000000),
+
+		RomanizeKeywords: envBool("ROMANIZE_KEYWORDS", false),
+
+		DefaultLocale: strings.ToLower(envOr("DEFAULT_LOCALE", "ko")),
+		ExtraLocales:  envList("SUGGEST_LOCALES"),
+
+		Channels: envList("SUGGEST_CHANNELS"),
+
+		WebhookURLs:      envList("WEBHOOK_URLS"),
+		WebhookSecret:    os.Getenv("WEBHOOK_SECRET"),
+		WebhookQueueSize: envInt("WEBHOOK_QUEUE_SIZE", 1000),
+		WebhookRetries:   envInt("WEBHOOK_MAX_RETRIES", 5),
+		WebhookTimeout:   envDuration("WEBHOOK_TIMEOUT", 5*time.Second),
+
+		CDCSource:       strings.ToLower(envOr("CDC_SOURCE", "kafka")),
+		CDCBrokers:      envList("CDC_KAFKA_BROKERS"),
+		CDCTopic:        strings.TrimSpace(os.Getenv("CDC_KAFKA_TOPIC")),
+		CDCGroup:        envOr("CDC_KAFKA_GROUP", "autocomplete-cdc"),
+		NATSURL:         strings.TrimSpace(os.Getenv("NATS_URL")),
+		CDCStream:       strings.TrimSpace(os.Getenv("CDC_NATS_STREAM")),
+		CDCSubject:      strings.TrimSpace(os.Getenv("CDC_NATS_SUBJECT")),
+		CDCDurable:      envOr("CDC_NATS_DURABLE", "autocomplete-cdc"),
+		CDCKeywordField: envOr("CDC_KEYWORD_FIELD", "name"),
+		CDCWeightField:  strings.TrimSpace(os.Getenv("CDC_WEIGHT_FIELD")),
+		CDCMetaFields:   envList("CDC_META_FIELDS"),
+	}
+}
+
+func (c config) indexSettings() indexSettings {
+	return indexSettings{
+		Shards:    c.IndexShards,
+		Replicas:  c.IndexReplicas,
+		MinGram:   c.IndexMinGram,
+		MaxGram:   c.IndexMaxGram,
+		Tokenizer: "standard",
+		Channels:  c.Channels,
+
+		EmbeddingDims: c.embeddingDims(),
+	}
+}
+
+// embeddingDims는 EMBEDDING_URL이 있을 때만 벡터 차원을 돌려줍니다.
+func (c config) embeddingDims() int {
+	if c.EmbeddingURL == "" {
+		return 0
+	}
+	return c.EmbeddingDims
+}
+
+// minPrefixLength는 접두어에 한글/한자/가나가 있으면 CJK 기준을, 아니면 기본 기준을 돌려줍니다.
+// 한 글자만으로도 후보가 충분히 좁혀지는 문자 체계와 라틴 문자의 기준을 따로 두기 위함입니다.
+func (c tunables) minPrefixLength(q string) int {
+	for _, r := range q {
+		if unicode.In(r, unicode.Hangul, unicode.Han, unicode.Hiragana, unicode.Katakana) {
+			return c.MinPrefixLengthCJK
+		}
+	}
+	return c.MinPrefixLength
+}
+
+func (c config) secondary() config {
+	sec := c
+	sec.Backend = c.SecondaryBackend
+	sec.ESURL = c.SecondaryURL
+	sec.ESUsername = c.SecondaryUsername
+	sec.ESPassword = c.SecondaryPassword
+	return sec
+}
+
+func (c config) replica(url string) config {
+	rep := c
+	rep.Backend = c.ReplicaBackend
+	rep.ESURL = url
+	rep.ESUsername = c.ReplicaUsername
+	rep.ESPassword = c.ReplicaPassword
+	return rep
+}
+
+func envOr(key, def string) string {
+	v := strings.TrimSpace(os.Getenv(key))
+	if v == "" {
+		return def
+	}
+	return v
+}
+
+func envBool(key string, def bool) bool {
+	v := strings.TrimSpace(os.Getenv(key))
+	if v == "" {
+		return def
+	}
+	b, err := strconv.ParseBool(v)
+	if err != nil {
+		return def
+	}
+	return b
+}
+
+func envInt(key string, def int) int {
+	v := strings.TrimSpace(os.Getenv(key))
+	if v == "" {
+		return def
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil {
+		return def
+	}
+	return n
+}
+
+func envFloat(key string, def float64) float64 {
+	v := strings.TrimSpace(os.Getenv(key))
+	if v == "" {
+		return def
+	}
+	f, err := strconv.ParseFloat(v, 64)
+	if err != nil {
+		return def
+	}
+	return f
+}
+
+func envDuration(key string, def time.Duration) time.Duration {
+	v := strings.TrimSpace(os.Getenv(key))
+	if v == "" {
+		return def
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil {
+		return def
+	}
+	return d
+}
+
+func envList(key string) []string {
+	var out []string
+	for _, v := range strings.Split(os.Getenv(key), ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			out = append(out, v)
+		}
+	}
+	return out
+}
+
+func envAge(key string, def time.Duration) time.Duration {
+	v := strings.TrimSpace(os.Getenv(key))
+	if v == "" {
+		return def
+	}
+	// "0"은 기능을 끄는 값으로 그대로 받습니다.
+	if v == "0" {
+		return 0
+	}
+	d, err := parseAge(v)
+	if err != nil {
+		return def
+	}
+	return d
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"expvar"
+)
+
+var suggestCorrected = expvar.NewMap("suggest_corrected_total")
+
+// fuzzyKey는 오타 보정 재조회가 켜져 있을 때만 보정 설정을 문자열로 돌려줍니다. ETag와 캐시 키에 씁니다.
+func (t tunables) fuzzyKey() string {
+	if !t.FuzzyRetry {
+		return ""
+	}
+	return "fuzzy=" + t.Fuzziness
+}
+
+// correctedPrefix는 fuzzy 결과 1순위 표기에서 입력과 편집 거리가 가장 가까운 접두어를 고릅니다.
+// completion suggester는 어느 접두어로 맞았는지 돌려주지 않으므로 입력 길이 ±2 범위의 접두어를 비교하고,
+// 거리가 같으면 입력 길이에 가까운 쪽을 씁니다.
+func correctedPrefix(q, top string) string {
+	want := []rune(matchKey(q))
+	text := []rune(top)
+	best, bestDist, bestGap := "", -1, 0
+	for n := len(want) - 2; n <= len(want)+2; n++ {
+		if n < 1 || n > len(text) {
+			continue
+		}
+		cand := string(text[:n])
+		dist := editDistance(want, []rune(matchKey(cand)))
+		gap := n - len(want)
+		if gap < 0 {
+			gap = -gap
+		}
+		if bestDist < 0 || dist < bestDist || (dist == bestDist && gap < bestGap) {
+			best, bestDist, bestGap = cand, dist, gap
+		}
+	}
+	return best
+}
+
+func editDistance(a, b []rune) int {
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(b)]
+}
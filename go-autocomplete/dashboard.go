@@ -0,0 +1,242 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	dashboardBucket    = time.Minute
+	dashboardRetention = 24 * time.Hour
+	// 분 단위 버킷 하나에 담는 검색어/클릭 키워드 수 상한입니다. 넘치면 overflow로만 셉니다.
+	dashboardMaxTerms = 2000
+	dashboardTopMax   = 100
+)
+
+// latencyBounds는 suggest 지연 히스토그램의 구간 상한(ms)입니다.
+var latencyBounds = []float64{1, 2, 5, 10, 20, 30, 50, 75, 100, 150, 200, 300, 500, 750, 1000, 2000, 5000}
+
+type dashboardSlot struct {
+	start       time.Time
+	queries     map[string]int
+	clicks      map[string]int
+	overflow    int
+	latency     []int64
+	suggests    int64
+	notModified int64
+	ingested    int64
+}
+
+// dashboard는 이 파드가 처리한 요청을 분 단위 링 버퍼로 모아 둡니다. 파드 간 합산은 하지 않습니다.
+type dashboard struct {
+	mu    sync.Mutex
+	slots []dashboardSlot
+}
+
+func newDashboard() *dashboard {
+	return &dashboard{slots: make([]dashboardSlot, int(dashboardRetention/dashboardBucket))}
+}
+
+// slot은 now가 속한 버킷을 돌려줍니다. 링을 한 바퀴 돈 버킷은 비우고 씁니다. mu를 잡고 호출합니다.
+func (d *dashboard) slot(now time.Time) *dashboardSlot {
+	start := now.Truncate(dashboardBucket)
+	sl := &d.slots[int(start.Unix()/int64(dashboardBucket/time.Second))%len(d.slots)]
+	if !sl.start.Equal(start) {
+		*sl = dashboardSlot{
+			start:   start,
+			queries: map[string]int{},
+			clicks:  map[string]int{},
+			latency: make([]int64, len(latencyBounds)+1),
+		}
+	}
+	return sl
+}
+
+func (sl *dashboardSlot) count(terms map[string]int, term string) {
+	if _, ok := terms[term]; !ok && len(terms) >= dashboardMaxTerms {
+		sl.overflow++
+		return
+	}
+	terms[term]++
+}
+
+func (d *dashboard) recordSuggest(q string, elapsed time.Duration, notModified bool) {
+	if d == nil {
+		return
+	}
+	ms := float64(elapsed) / float64(time.Millisecond)
+	i := sort.SearchFloat64s(latencyBounds, ms)
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	sl := d.slot(time.Now())
+	sl.count(sl.queries, matchKey(q))
+	sl.latency[i]++
+	sl.suggests++
+	if notModified {
+		sl.notModified++
+	}
+}
+
+func (d *dashboard) recordClick(keyword string) {
+	if d == nil {
+		return
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	sl := d.slot(time.Now())
+	sl.count(sl.clicks, matchKey(keyword))
+}
+
+func (d *dashboard) recordIngested(n int) {
+	if d == nil {
+		return
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.slot(time.Now()).ingested += int64(n)
+}
+
+type termCount struct {
+	Term  string `json:"term"`
+	Count int    `json:"count"`
+}
+
+type dashboardSummary struct {
+	From        time.Time          `json:"from"`
+	To          time.Time          `json:"to"`
+	TopQueries  []termCount        `json:"top_queries"`
+	TopClicks   []termCount        `json:"top_clicks"`
+	Overflow    int                `json:"untracked_terms"`
+	Suggests    int64              `json:"suggest_requests"`
+	Latency     map[string]float64 `json:"suggest_latency_ms"`
+	NotModified float64            `json:"etag_hit_rate"`
+	Ingested    int64              `json:"ingested_keywords"`
+	IngestRate  float64            `json:"ingested_per_second"`
+}
+
+func (d *dashboard) summary(now time.Time, window time.Duration, top int) dashboardSummary {
+	from := now.Add(-window)
+	queries, clicks := map[string]int{}, map[string]int{}
+	latency := make([]int64, len(latencyBounds)+1)
+	out := dashboardSummary{From: from.UTC(), To: now.UTC()}
+
+	d.mu.Lock()
+	for _, sl := range d.slots {
+		if sl.start.IsZero() || !sl.start.After(from.Add(-dashboardBucket)) || sl.start.After(now) {
+			continue
+		}
+		for term, n := range sl.queries {
+			queries[term] += n
+		}
+		for term, n := range sl.clicks {
+			clicks[term] += n
+		}
+		for i, n := range sl.latency {
+			latency[i] += n
+		}
+		out.Overflow += sl.overflow
+		out.Suggests += sl.suggests
+		out.Ingested += sl.ingested
+		out.NotModified += float64(sl.notModified)
+	}
+	d.mu.Unlock()
+
+	out.TopQueries = topTerms(queries, top)
+	out.TopClicks = topTerms(clicks, top)
+	if out.Suggests > 0 {
+		out.NotModified /= float64(out.Suggests)
+	}
+	out.IngestRate = float64(out.Ingested) / window.Seconds()
+	out.Latency = map[string]float64{
+		"p50": percentile(latency, out.Suggests, 0.50),
+		"p90": percentile(latency, out.Suggests, 0.90),
+		"p99": percentile(latency, out.Suggests, 0.99),
+	}
+	return out
+}
+
+func topTerms(counts map[string]int, top int) []termCount {
+	out := make([]termCount, 0, len(counts))
+	for term, n := range counts {
+		out = append(out, termCount{Term: term, Count: n})
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Count != out[j].Count {
+			return out[i].Count > out[j].Count
+		}
+		return out[i].Term < out[j].Term
+	})
+	if len(out) > top {
+		out = out[:top]
+	}
+	return out
+}
+
+// percentile은 히스토그램에서 p 분위가 속한 구간의 상한을 돌려줍니다. 마지막 구간을 넘으면 가장 큰 상한입니다.
+func percentile(hist []int64, total int64, p float64) float64 {
+	if total == 0 {
+		return 0
+	}
+	rank := int64(p*float64(total) + 0.5)
+	if rank < 1 {
+		rank = 1
+	}
+	var seen int64
+	for i, n := range hist {
+		seen += n
+		if seen >= rank {
+			if i < len(latencyBounds) {
+				return latencyBounds[i]
+			}
+			break
+		}
+	}
+	return latencyBounds[len(latencyBounds)-1]
+}
+
+func (s *server) handleDashboard(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		methodNotAllowed(w, r, http.MethodGet)
+		return
+	}
+	window := time.Hour
+	if v := r.URL.Query().Get("window"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil || d < dashboardBucket || d > dashboardRetention {
+			invalidParameter(w, r, "window")
+			return
+		}
+		window = d
+	}
+	top := 20
+	if v := r.URL.Query().Get("top"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > dashboardTopMax {
+			invalidParameter(w, r, "top")
+			return
+		}
+		top = n
+	}
+
+	summary := s.dash.summary(time.Now(), window, top)
+	payload := map[string]interface{}{
+		"window":  window.String(),
+		"summary": summary,
+	}
+	// ES 캐시 적중률은 인덱스 누적값이라 window와 무관합니다. 조회에 실패해도 나머지는 내려줍니다.
+	ctx, cancel := context.WithTimeout(r.Context(), s.cfg.AdminTimeout)
+	defer cancel()
+	if idx, err := s.st.indexStats(ctx); err != nil {
+		logf(r.Context(), "인덱스 통계 조회 실패: %v", err)
+	} else {
+		payload["es_cache"] = map[string]float64{
+			"query_cache_hit_rate":   idx.QueryCache,
+			"request_cache_hit_rate": idx.RequestCache,
+		}
+	}
+	writeJSON(w, payload)
+}
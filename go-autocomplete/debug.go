@@ -0,0 +1,64 @@
+package main
+
+import (
+	"expvar"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/pprof"
+	"runtime"
+	"runtime/debug"
+	rtpprof "runtime/pprof"
+	"time"
+)
+
+// debugServer는 pprof, expvar, GC 통계와 관리 API(admin)를 루프백 전용 포트에서만 노출합니다.
+func debugServer(addr string, admin http.Handler) (*http.Server, error) {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return nil, fmt.Errorf("디버그 주소 형식 오류 (%s): %w", addr, err)
+	}
+	if ip := net.ParseIP(host); host != "localhost" && (ip == nil || !ip.IsLoopback()) {
+		return nil, fmt.Errorf("디버그 포트는 루프백 주소에만 바인딩할 수 있음: %s", addr)
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/debug/pprof/", pprof.Index)
+	mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+	mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+	mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+	mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+	mux.Handle("/debug/vars", expvar.Handler())
+	mux.HandleFunc("/debug/gc", handleGCStats)
+	mux.HandleFunc("/debug/goroutines", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		_ = rtpprof.Lookup("goroutine").WriteTo(w, 2)
+	})
+	mux.Handle(adminPathPrefix, admin)
+
+	return &http.Server{
+		Addr:              addr,
+		Handler:           mux,
+		ReadHeaderTimeout: 3 * time.Second,
+	}, nil
+}
+
+func handleGCStats(w http.ResponseWriter, r *http.Request) {
+	var gc debug.GCStats
+	debug.ReadGCStats(&gc)
+	var mem runtime.MemStats
+	runtime.ReadMemStats(&mem)
+
+	writeJSON(w, map[string]interface{}{
+		"num_gc":          gc.NumGC,
+		"last_gc":         gc.LastGC,
+		"pause_total_ms":  gc.PauseTotal.Milliseconds(),
+		"recent_pauses":   gc.Pause,
+		"heap_alloc":      mem.HeapAlloc,
+		"heap_inuse":      mem.HeapInuse,
+		"heap_objects":    mem.HeapObjects,
+		"next_gc":         mem.NextGC,
+		"gc_cpu_fraction": mem.GCCPUFraction,
+		"goroutines":      runtime.NumGoroutine(),
+	})
+}
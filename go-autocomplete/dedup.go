@@ -0,0 +1,69 @@
+package main
+
+import (
+	"expvar"
+	"sort"
+	"time"
+)
+
+var suggestDedupMerged = expvar.NewInt("suggest_dedup_merged_total")
+
+type scoredSuggestion struct {
+	Text    string
+	Weight  float64
+	Keyword string
+	Meta    map[string]interface{}
+	// Score는 응답 순서를 정한 최종 점수입니다. rerank(의미 기반은 유사도)가 채웁니다.
+	Score float64
+
+	LastSeenAt   *time.Time
+	ClickScore   float64
+	ClickScoreAt time.Time
+}
+
+// dedupSuggestions는 대소문자·전각 등 표기만 다른 후보("Nike", "nike", "NIKE")를 matchKey 기준으로 합칩니다.
+// 가중치가 가장 높은 표기를 남기고, 합쳐진 후보들의 가중치를 더해 다시 정렬합니다.
+// 정규화 도입 전에 따로 들어간 문서나 출처가 다른 데이터가 섞인 경우를 위한 것입니다.
+func dedupSuggestions(in []scoredSuggestion, limit int) []scoredSuggestion {
+	groups := make(map[string]int, len(in))
+	merged := make([]scoredSuggestion, 0, len(in))
+	best := make([]float64, 0, len(in))
+	for _, c := range in {
+		key := matchKey(c.Text)
+		i, ok := groups[key]
+		if !ok {
+			groups[key] = len(merged)
+			merged = append(merged, c)
+			best = append(best, c.Weight)
+			continue
+		}
+		suggestDedupMerged.Add(1)
+		merged[i].Weight += c.Weight
+		if c.Weight > best[i] {
+			total := merged[i].Weight
+			merged[i], best[i] = c, c.Weight
+			merged[i].Weight = total
+		}
+	}
+	sort.SliceStable(merged, func(a, b int) bool { return merged[a].Weight > merged[b].Weight })
+
+	if len(merged) > limit {
+		merged = merged[:limit]
+	}
+	return merged
+}
+
+func topSuggestions(in []scoredSuggestion, n int) []scoredSuggestion {
+	if len(in) > n {
+		return in[:n]
+	}
+	return in
+}
+
+func suggestionTexts(in []scoredSuggestion) []string {
+	out := make([]string, len(in))
+	for i, c := range in {
+		out[i] = c.Text
+	}
+	return out
+}
@@ -0,0 +1,154 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/elastic/go-elasticsearch/v8/esapi"
+)
+
+func (s *store) countQuery(ctx context.Context, query map[string]interface{}) (int, error) {
+	body, err := json.Marshal(map[string]interface{}{"query": query})
+	if err != nil {
+		return 0, fmt.Errorf("쿼리 직렬화 실패: %w", err)
+	}
+	res, err := esapi.CountRequest{Index: []string{s.index}, Body: bytes.NewReader(body)}.Do(ctx, s.client)
+	if err != nil {
+		return 0, fmt.Errorf("count 요청 실패: %w", err)
+	}
+	defer discard(res.Body)
+	if res.IsError() {
+		return 0, fmt.Errorf("count 응답 에러: %s", res.String())
+	}
+	var parsed struct {
+		Count int `json:"count"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&parsed); err != nil {
+		return 0, fmt.Errorf("응답 파싱 실패: %w", err)
+	}
+	return parsed.Count, nil
+}
+
+func (s *store) deleteByQuery(ctx context.Context, query map[string]interface{}) (int, error) {
+	body, err := json.Marshal(map[string]interface{}{"query": query})
+	if err != nil {
+		return 0, fmt.Errorf("쿼리 직렬화 실패: %w", err)
+	}
+	res, err := esapi.DeleteByQueryRequest{
+		Index:     []string{s.index},
+		Body:      bytes.NewReader(body),
+		Conflicts: "proceed",
+	}.Do(ctx, s.client)
+	if err != nil {
+		return 0, fmt.Errorf("delete-by-query 요청 실패: %w", err)
+	}
+	defer discard(res.Body)
+	if res.IsError() {
+		return 0, fmt.Errorf("delete-by-query 응답 에러: %s", res.String())
+	}
+	var parsed struct {
+		Deleted int `json:"deleted"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&parsed); err != nil {
+		return 0, fmt.Errorf("응답 파싱 실패: %w", err)
+	}
+	return parsed.Deleted, nil
+}
+
+// prefixQuery는 keyword 필드에 대한 접두어 조건입니다. keyword 필드에는 matchKey 형태가 들어 있으므로
+// prefix도 matchKey로 정규화해 넘겨야 전각·폭 없는 문자 변형을 놓치지 않습니다.
+func prefixQuery(prefix string) map[string]interface{} {
+	return map[string]interface{}{
+		"prefix": map[string]interface{}{
+			"keyword": map[string]interface{}{"value": prefix, "case_insensitive": true},
+		},
+	}
+}
+
+// handleAdminDelete는 잘못된 일괄 적재를 치우기 위한 DELETE /admin/keywords입니다.
+// 기본은 dry-run이라 대상 건수만 돌려주고, 실제 삭제는 dry_run=false와 함께
+// 직전 dry-run 건수를 expected_count로 보내야 하며 건수가 달라졌으면 409로 거절합니다.
+func (s *server) handleAdminDelete(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		methodNotAllowed(w, r, http.MethodDelete)
+		return
+	}
+	params := r.URL.Query()
+	raw := strings.TrimSpace(params.Get("prefix"))
+	prefix := matchKey(raw)
+
+	var query map[string]interface{}
+	switch {
+	case raw != "":
+		// 정규화 후 비는 접두어는 모든 키워드에 맞으므로 받지 않습니다.
+		if prefix == "" {
+			invalidParameter(w, r, "prefix")
+			return
+		}
+		query = prefixQuery(prefix)
+	case r.ContentLength != 0:
+		var body struct {
+			Query map[string]interface{} `json:"query"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil || len(body.Query) == 0 {
+			writeError(w, r, codeBadRequestBody)
+			return
+		}
+		query = body.Query
+	default:
+		missingParameter(w, r, "prefix")
+		return
+	}
+
+	locale, ok := s.localeParam(w, r, params.Get("locale"))
+	if !ok {
+		return
+	}
+	st := s.st.withLocale(locale)
+
+	ctx, cancel := context.WithTimeout(r.Context(), s.cfg.AdminTimeout)
+	defer cancel()
+	if s.softDelete() {
+		query = notDeleted(query)
+	}
+	matched, err := st.countQuery(ctx, query)
+	if err != nil {
+		logf(r.Context(), "삭제 대상 집계 실패: %v", err)
+		writeError(w, r, codeDeleteFailed)
+		return
+	}
+	if params.Get("dry_run") != "false" {
+		writeJSON(w, map[string]interface{}{"dry_run": true, "matched": matched})
+		return
+	}
+
+	expected, err := strconv.Atoi(params.Get("expected_count"))
+	if err != nil {
+		missingParameter(w, r, "expected_count")
+		return
+	}
+	if expected != matched {
+		writeErrorDetails(w, r, codeDeleteCountChanged, map[string]int{"expected": expected, "matched": matched})
+		return
+	}
+	deleted, err := s.removeByQuery(ctx, st, query)
+	if err != nil {
+		logf(r.Context(), "delete-by-query 실패: %v", err)
+		writeError(w, r, codeDeleteFailed)
+		return
+	}
+	logf(r.Context(), "관리자 삭제: %d건 (prefix=%q)", deleted, prefix)
+	if deleted > 0 {
+		s.hooks.publish(webhookEvent{Type: eventKeywordsDeleted, Reason: "admin", Prefix: prefix, Locale: locale, Count: deleted})
+	}
+	s.mir.enqueue("delete-by-query", prefix, func(ctx context.Context, st *store) error {
+		_, err := s.removeByQuery(ctx, st.withLocale(locale), query)
+		return err
+	})
+	writeJSON(w, map[string]interface{}{"dry_run": false, "matched": matched, "deleted": deleted})
+}
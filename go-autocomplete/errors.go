@@ -0,0 +1,103 @@
+package main
+
+import (
+	"net/http"
+)
+
+// 오류 코드는 프론트엔드/게이트웨이가 분기하는 계약이므로 한번 공개한 값은 바꾸지 않습니다.
+const (
+	codeBadRequestBody        = "BAD_REQUEST_BODY"
+	codeMissingParameter      = "MISSING_PARAMETER"
+	codeEmptyKeyword          = "EMPTY_KEYWORD"
+	codeInvalidCharacters     = "INVALID_CHARACTERS"
+	codeMethodNotAllowed      = "METHOD_NOT_ALLOWED"
+	codeJobNotFound           = "JOB_NOT_FOUND"
+	codeKeywordNotFound       = "KEYWORD_NOT_FOUND"
+	codeInvalidParameter      = "INVALID_PARAMETER"
+	codeUnauthorized          = "UNAUTHORIZED"
+	codeWriteQueueFull        = "WRITE_QUEUE_FULL"
+	codeTooManyRequests       = "TOO_MANY_REQUESTS"
+	codeServiceOverloaded     = "SERVICE_OVERLOADED"
+	codeUpstreamTimeout       = "UPSTREAM_TIMEOUT"
+	codeUpsertFailed          = "UPSERT_FAILED"
+	codeSearchFailed          = "SEARCH_FAILED"
+	codeSnapshotInProgress    = "SNAPSHOT_IN_PROGRESS"
+	codeSnapshotFailed        = "SNAPSHOT_FAILED"
+	codeRestoreInProgress     = "RESTORE_IN_PROGRESS"
+	codeGCFailed              = "GC_FAILED"
+	codeDeleteFailed          = "DELETE_FAILED"
+	codeDeleteCountChanged    = "DELETE_COUNT_CHANGED"
+	codeIndexSettingsFailed   = "INDEX_SETTINGS_FAILED"
+	codeReloadFailed          = "RELOAD_FAILED"
+	codeIdempotencyInProgress = "IDEMPOTENCY_IN_PROGRESS"
+	codeIdempotencyKeyReused  = "IDEMPOTENCY_KEY_REUSED"
+	codeInternal              = "INTERNAL"
+)
+
+// errorStatus는 오류 코드별 HTTP 상태입니다. 사용자 메시지는 locales/*.json에서 코드로 찾습니다.
+var errorStatus = map[string]int{
+	codeBadRequestBody:        http.StatusBadRequest,
+	codeMissingParameter:      http.StatusBadRequest,
+	codeEmptyKeyword:          http.StatusBadRequest,
+	codeInvalidCharacters:     http.StatusBadRequest,
+	codeMethodNotAllowed:      http.StatusMethodNotAllowed,
+	codeJobNotFound:           http.StatusNotFound,
+	codeKeywordNotFound:       http.StatusNotFound,
+	codeInvalidParameter:      http.StatusBadRequest,
+	codeUnauthorized:          http.StatusUnauthorized,
+	codeWriteQueueFull:        http.StatusServiceUnavailable,
+	codeTooManyRequests:       http.StatusTooManyRequests,
+	codeServiceOverloaded:     http.StatusServiceUnavailable,
+	codeUpstreamTimeout:       http.StatusGatewayTimeout,
+	codeUpsertFailed:          http.StatusInternalServerError,
+	codeSearchFailed:          http.StatusInternalServerError,
+	codeSnapshotInProgress:    http.StatusConflict,
+	codeSnapshotFailed:        http.StatusInternalServerError,
+	codeRestoreInProgress:     http.StatusConflict,
+	codeGCFailed:              http.StatusInternalServerError,
+	codeDeleteFailed:          http.StatusInternalServerError,
+	codeDeleteCountChanged:    http.StatusConflict,
+	codeIndexSettingsFailed:   http.StatusInternalServerError,
+	codeReloadFailed:          http.StatusUnprocessableEntity,
+	codeIdempotencyInProgress: http.StatusConflict,
+	codeIdempotencyKeyReused:  http.StatusUnprocessableEntity,
+	codeInternal:              http.StatusInternalServerError,
+}
+
+type errorResponse struct {
+	Code      string      `json:"code"`
+	Message   string      `json:"message"`
+	Details   interface{} `json:"details,omitempty"`
+	RequestID string      `json:"request_id,omitempty"`
+}
+
+func writeError(w http.ResponseWriter, r *http.Request, code string) {
+	writeErrorDetails(w, r, code, nil)
+}
+
+func writeErrorDetails(w http.ResponseWriter, r *http.Request, code string, details interface{}) {
+	status, ok := errorStatus[code]
+	if !ok {
+		code, status = codeInternal, errorStatus[codeInternal]
+	}
+	w.Header().Set("Content-Language", requestLocale(r))
+	writeJSONStatus(w, status, errorResponse{
+		Code:      code,
+		Message:   localize(r, code),
+		Details:   details,
+		RequestID: requestIDFrom(r.Context()),
+	})
+}
+
+func methodNotAllowed(w http.ResponseWriter, r *http.Request, allowed string) {
+	w.Header().Set("Allow", allowed)
+	writeErrorDetails(w, r, codeMethodNotAllowed, map[string]string{"allowed": allowed})
+}
+
+func invalidParameter(w http.ResponseWriter, r *http.Request, name string) {
+	writeErrorDetails(w, r, codeInvalidParameter, map[string]string{"parameter": name})
+}
+
+func missingParameter(w http.ResponseWriter, r *http.Request, name string) {
+	writeErrorDetails(w, r, codeMissingParameter, map[string]string{"parameter": name})
+}
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/elastic/go-elasticsearch/v8/esapi"
+)
+
+// generation은 인덱스 내용이 바뀌었는지 판단하는 값입니다. 별칭이 가리키는 실제 인덱스 이름과
+// primary 샤드의 색인·삭제 누적 건수로 만들므로, 어느 파드에서 쓰든 복원으로 인덱스가 바뀌든 달라집니다.
+// expires_at은 색인 없이 시간이 지나 만료되므로, 정리 작업이 지우기 전의 만료 문서 수도 함께 넣습니다.
+func (s *store) generation(ctx context.Context) (string, error) {
+	res, err := esapi.IndicesStatsRequest{
+		Index:  []string{s.index},
+		Metric: []string{"indexing"},
+	}.Do(ctx, s.client)
+	if err != nil {
+		return "", fmt.Errorf("인덱스 통계 요청 실패: %w", err)
+	}
+	defer discard(res.Body)
+	if res.IsError() {
+		return "", fmt.Errorf("인덱스 통계 응답 에러: %s", res.String())
+	}
+
+	var parsed struct {
+		All struct {
+			Primaries struct {
+				Indexing struct {
+					IndexTotal  int64 `json:"index_total"`
+					DeleteTotal int64 `json:"delete_total"`
+				} `json:"indexing"`
+			} `json:"primaries"`
+		} `json:"_all"`
+		Indices map[string]json.RawMessage `json:"indices"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&parsed); err != nil {
+		return "", fmt.Errorf("응답 파싱 실패: %w", err)
+	}
+	names := make([]string, 0, len(parsed.Indices))
+	for name := range parsed.Indices {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	expired, err := s.countQuery(ctx, map[string]interface{}{
+		"range": map[string]interface{}{"expires_at": map[string]interface{}{"lte": "now"}},
+	})
+	if err != nil {
+		return "", err
+	}
+	idx := parsed.All.Primaries.Indexing
+	return fmt.Sprintf("%s:%d:%d:%d", strings.Join(names, ","), idx.IndexTotal, idx.DeleteTotal, expired), nil
+}
+
+// generations는 로케일별 인덱스 generation을 주기적으로 갱신해 둡니다.
+// suggest마다 통계를 조회하지 않으려는 것이라 갱신 주기만큼 ETag가 늦게 바뀔 수 있습니다.
+type generations struct {
+	mu       sync.RWMutex
+	byLocale map[string]string
+}
+
+func (g *generations) get(locale string) (string, bool) {
+	if g == nil {
+		return "", false
+	}
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	gen, ok := g.byLocale[locale]
+	return gen, ok
+}
+
+func (g *generations) refreshLoop(ctx context.Context, st *store, locales []string, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			g.refresh(ctx, st, locales)
+		}
+	}
+}
+
+func (g *generations) refresh(ctx context.Context, st *store, locales []string) {
+	next := make(map[string]string, len(locales))
+	for _, locale := range locales {
+		gen, err := st.withLocale(locale).generation(ctx)
+		if err != nil {
+			// 모르는 상태로 ETag를 내주면 바뀐 결과를 304로 숨길 수 있으므로 해당 로케일은 비워 둡니다.
+			log.Printf("인덱스 generation 갱신 실패 (%s): %v", localeIndexName(locale), err)
+			continue
+		}
+		next[locale] = gen
+	}
+	g.mu.Lock()
+	g.byLocale = next
+	g.mu.Unlock()
+}
+
+// suggestETag는 정규화한 접두어와 generation으로 약한 ETag를 만듭니다.
+func suggestETag(gen, locale, channel, q string) string {
+	sum := sha256.Sum256([]byte(strings.Join([]string{gen, locale, channel, matchKey(q)}, "\x00")))
+	return `W/"` + hex.EncodeToString(sum[:12]) + `"`
+}
+
+// etagMatches는 If-None-Match를 약한 비교로 확인합니다.
+func etagMatches(header, etag string) bool {
+	if header == "" {
+		return false
+	}
+	want := strings.TrimPrefix(etag, "W/")
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == want {
+			return true
+		}
+	}
+	return false
+}
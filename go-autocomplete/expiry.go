@@ -0,0 +1,62 @@
+package main
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+// notExpiredQuery는 만료되지 않았고 소프트 삭제되지 않은 키워드를 고릅니다.
+func notExpiredQuery() map[string]interface{} {
+	return map[string]interface{}{
+		"bool": map[string]interface{}{
+			"must_not": []interface{}{
+				map[string]interface{}{"range": map[string]interface{}{
+					"expires_at": map[string]interface{}{"lte": "now"},
+				}},
+				map[string]interface{}{"exists": map[string]interface{}{"field": "deleted_at"}},
+			},
+		},
+	}
+}
+
+// expiredQuery는 expires_at이 지난 키워드를 고릅니다.
+func expiredQuery() map[string]interface{} {
+	return map[string]interface{}{
+		"range": map[string]interface{}{
+			"expires_at": map[string]interface{}{"lte": "now"},
+		},
+	}
+}
+
+func (s *server) expiryCleanupLoop(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			for _, locale := range s.cfg.locales() {
+				locale := locale
+				ls := s.st.withLocale(locale)
+				deleted, err := s.removeByQuery(ctx, ls, expiredQuery())
+				if err != nil {
+					log.Printf("만료 키워드 정리 실패 (%s): %v", ls.index, err)
+					continue
+				}
+				if deleted > 0 {
+					log.Printf("만료 키워드 %d건 삭제 (%s)", deleted, ls.index)
+					s.hooks.publish(webhookEvent{Type: eventKeywordsDeleted, Reason: "expired", Locale: locale, Count: deleted})
+				}
+				s.mir.enqueue("delete-expired", ls.index, func(ctx context.Context, st *store) error {
+					_, err := s.removeByQuery(ctx, st.withLocale(locale), expiredQuery())
+					return err
+				})
+			}
+			if s.softDelete() {
+				s.purgeTombstones(ctx)
+			}
+		}
+	}
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"expvar"
+	"sync"
+	"time"
+)
+
+var failoverTotal = expvar.NewMap("suggest_failover_total")
+
+// readRouter는 /suggest 읽기를 등록 순서(기본 → 복제본)대로 라우팅합니다.
+// 오류를 내거나 임계 시간 안에 응답하지 못한 클러스터는 cooldown 동안 건너뜁니다.
+type readRouter struct {
+	endpoints []*readEndpoint
+	threshold time.Duration
+	cooldown  time.Duration
+}
+
+type readEndpoint struct {
+	name string
+	st   *store
+
+	mu        sync.Mutex
+	downUntil time.Time
+}
+
+func newReadRouter(primary *store, threshold, cooldown time.Duration) *readRouter {
+	return &readRouter{
+		endpoints: []*readEndpoint{{name: "primary", st: primary}},
+		threshold: threshold,
+		cooldown:  cooldown,
+	}
+}
+
+func (r *readRouter) addReplica(name string, st *store) {
+	r.endpoints = append(r.endpoints, &readEndpoint{name: name, st: st})
+}
+
+func (r *readRouter) suggest(ctx context.Context, locale, channel, q, fuzziness string) ([]scoredSuggestion, error) {
+	if len(r.endpoints) == 1 {
+		return r.endpoints[0].st.withLocale(locale).suggest(ctx, q, channel, fuzziness)
+	}
+
+	candidates := r.healthy()
+	var lastErr error
+	for _, ep := range candidates {
+		attemptCtx, cancel := context.WithTimeout(ctx, r.threshold)
+		out, err := ep.st.withLocale(locale).suggest(attemptCtx, q, channel, fuzziness)
+		cancel()
+		if err == nil {
+			if ep != r.endpoints[0] {
+				failoverTotal.Add(ep.name, 1)
+			}
+			return out, nil
+		}
+		if errors.Is(err, errESSaturated) {
+			return nil, err
+		}
+		lastErr = err
+		ep.markDown(r.cooldown)
+		logf(ctx, "suggest 읽기 실패, 다음 클러스터로 전환 (%s): %v", ep.name, err)
+	}
+	return nil, lastErr
+}
+
+func (r *readRouter) healthy() []*readEndpoint {
+	now := time.Now()
+	var up, down []*readEndpoint
+	for _, ep := range r.endpoints {
+		if ep.isDown(now) {
+			down = append(down, ep)
+			continue
+		}
+		up = append(up, ep)
+	}
+	// 모두 down이면 순서대로 다시 시도합니다.
+	return append(up, down...)
+}
+
+func (ep *readEndpoint) markDown(cooldown time.Duration) {
+	ep.mu.Lock()
+	ep.downUntil = time.Now().Add(cooldown)
+	ep.mu.Unlock()
+}
+
+func (ep *readEndpoint) isDown(now time.Time) bool {
+	ep.mu.Lock()
+	defer ep.mu.Unlock()
+	return now.Before(ep.downUntil)
+}
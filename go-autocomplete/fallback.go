@@ -0,0 +1,91 @@
+package main
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+)
+
+const fallbackHeader = "X-Suggest-Fallback"
+
+// fallbackTrie는 ES 장애 시 /suggest를 계속 응답하기 위한 상위 N개 키워드의 접두어 트리입니다.
+// 가중치 내림차순으로 삽입하므로 각 노드의 top 목록이 곧 해당 접두어의 상위 결과입니다.
+// 삽입과 조회 모두 matchKey로 정규화해 ES 조회와 같은 입력을 같은 키로 봅니다.
+type fallbackTrie struct {
+	mu   sync.RWMutex
+	root *trieNode
+}
+
+type trieNode struct {
+	children map[rune]*trieNode
+	top      []string
+}
+
+func newTrieNode() *trieNode {
+	return &trieNode{children: map[rune]*trieNode{}}
+}
+
+func buildTrie(keywords []keywordWeight, perNode int) *trieNode {
+	root := newTrieNode()
+	for _, kw := range keywords {
+		node := root
+		for _, r := range matchKey(kw.Keyword) {
+			child, ok := node.children[r]
+			if !ok {
+				child = newTrieNode()
+				node.children[r] = child
+			}
+			node = child
+			if len(node.top) < perNode {
+				node.top = append(node.top, kw.Keyword)
+			}
+		}
+	}
+	return root
+}
+
+func (t *fallbackTrie) suggest(q string) ([]string, bool) {
+	t.mu.RLock()
+	node := t.root
+	t.mu.RUnlock()
+	if node == nil {
+		return nil, false
+	}
+	for _, r := range matchKey(q) {
+		node = node.children[r]
+		if node == nil {
+			return []string{}, true
+		}
+	}
+	out := make([]string, len(node.top))
+	copy(out, node.top)
+	return out, true
+}
+
+func (t *fallbackTrie) refreshLoop(ctx context.Context, st *store, topN int, interval time.Duration) {
+	t.refresh(ctx, st, topN)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			t.refresh(ctx, st, topN)
+		}
+	}
+}
+
+func (t *fallbackTrie) refresh(ctx context.Context, st *store, topN int) {
+	keywords, err := st.topKeywords(ctx, topN)
+	if err != nil {
+		log.Printf("fallback 트리 갱신 실패 (기존 트리 유지): %v", err)
+		return
+	}
+	root := buildTrie(keywords, suggestSize)
+	t.mu.Lock()
+	t.root = root
+	t.mu.Unlock()
+	log.Printf("fallback 트리 갱신: 키워드 %d개", len(keywords))
+}
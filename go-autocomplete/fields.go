@@ -0,0 +1,110 @@
+package main
+
+import (
+	"net/http"
+	"strings"
+)
+
+// suggestFields는 fields 파라미터로 고른 suggest 항목 필드입니다. 비어 있으면 기존처럼 문자열 배열로 응답합니다.
+// meta.<키>는 점으로 중첩 키를 가리키며 응답에서도 같은 구조로 들어갑니다.
+type suggestFields []string
+
+var suggestFieldNames = map[string]bool{"text": true, "score": true, "keyword": true, "meta": true}
+
+func fieldsParam(w http.ResponseWriter, r *http.Request) (suggestFields, bool) {
+	raw := strings.TrimSpace(r.URL.Query().Get("fields"))
+	if raw == "" {
+		return nil, true
+	}
+	var (
+		out       suggestFields
+		seen      = map[string]bool{}
+		wholeMeta bool
+	)
+	for _, f := range strings.Split(raw, ",") {
+		f = strings.TrimSpace(f)
+		top, rest, nested := strings.Cut(f, ".")
+		if !suggestFieldNames[top] || (nested && (top != "meta" || strings.Contains("."+rest+".", ".."))) {
+			invalidParameter(w, r, "fields")
+			return nil, false
+		}
+		if !seen[f] {
+			seen[f] = true
+			out = append(out, f)
+		}
+		wholeMeta = wholeMeta || f == "meta"
+	}
+	// meta 전체를 고르면 meta.<키>는 이미 포함됩니다.
+	if wholeMeta {
+		kept := out[:0]
+		for _, f := range out {
+			if !strings.HasPrefix(f, "meta.") {
+				kept = append(kept, f)
+			}
+		}
+		out = kept
+	}
+	return out, true
+}
+
+// key는 ETag 변형에 넣을 정규화한 필드 목록입니다.
+func (fs suggestFields) key() string {
+	return strings.Join(fs, ",")
+}
+
+// project는 고른 필드만 남긴 항목을 만듭니다. 값이 없는 필드(fallback 트리 결과의 meta 등)는 생략합니다.
+func (fs suggestFields) project(items []scoredSuggestion) []map[string]interface{} {
+	out := make([]map[string]interface{}, 0, len(items))
+	for _, it := range items {
+		m := map[string]interface{}{}
+		for _, f := range fs {
+			switch f {
+			case "text":
+				m["text"] = it.Text
+			case "score":
+				m["score"] = it.Score
+			case "keyword":
+				if it.Keyword != "" {
+					m["keyword"] = it.Keyword
+				}
+			case "meta":
+				if len(it.Meta) > 0 {
+					m["meta"] = it.Meta
+				}
+			default:
+				path := strings.Split(strings.TrimPrefix(f, "meta."), ".")
+				if v, ok := lookupMeta(it.Meta, path); ok {
+					setPath(m, append([]string{"meta"}, path...), v)
+				}
+			}
+		}
+		out = append(out, m)
+	}
+	return out
+}
+
+func lookupMeta(meta map[string]interface{}, path []string) (interface{}, bool) {
+	var cur interface{} = meta
+	for _, k := range path {
+		m, ok := cur.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		if cur, ok = m[k]; !ok {
+			return nil, false
+		}
+	}
+	return cur, true
+}
+
+func setPath(m map[string]interface{}, path []string, v interface{}) {
+	for _, k := range path[:len(path)-1] {
+		next, ok := m[k].(map[string]interface{})
+		if !ok {
+			next = map[string]interface{}{}
+			m[k] = next
+		}
+		m = next
+	}
+	m[path[len(path)-1]] = v
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var errKeywordNotFound = errors.New("키워드 없음")
+
+// recordClick은 사용자가 고른 추천어의 last_clicked_at과 감쇠 클릭 점수(click_score)를 갱신합니다.
+func (s *store) recordClick(ctx context.Context, keyword string, halfLife time.Duration) error {
+	if err := s.updateScript(ctx, keyword, clickBody(time.Now(), halfLife)); err != nil {
+		return fmt.Errorf("클릭 기록 실패: %w", err)
+	}
+	return nil
+}
+
+// staleQuery는 cutoff 이후로 업서트도 클릭도 없었던 키워드를 고릅니다.
+// last_seen_at이 없는(추적 이전에 색인된) 문서는 다음 업서트 전까지 건드리지 않습니다.
+func staleQuery(cutoff time.Time) map[string]interface{} {
+	return map[string]interface{}{
+		"bool": map[string]interface{}{
+			"must": map[string]interface{}{
+				"range": map[string]interface{}{"last_seen_at": map[string]interface{}{"lt": cutoff}},
+			},
+			"must_not": map[string]interface{}{
+				"range": map[string]interface{}{"last_clicked_at": map[string]interface{}{"gte": cutoff}},
+			},
+		},
+	}
+}
+
+// gc는 모든 로케일에서 비활성 키워드를 지웁니다. 소프트 삭제가 켜져 있으면 tombstone으로 남겨 보관 기간
+// 안에는 /keywords/{keyword}/restore로 되살릴 수 있습니다.
+func (s *server) gc(ctx context.Context, olderThan time.Duration) (int, error) {
+	cutoff := time.Now().Add(-olderThan)
+	total := 0
+	for _, locale := range s.cfg.locales() {
+		locale := locale
+		deleted, err := s.removeByQuery(ctx, s.st.withLocale(locale), staleQuery(cutoff))
+		if err != nil {
+			return total, err
+		}
+		total += deleted
+		if deleted > 0 {
+			s.hooks.publish(webhookEvent{Type: eventKeywordsDeleted, Reason: "gc", Locale: locale, Count: deleted})
+		}
+		s.mir.enqueue("gc", cutoff.Format(time.RFC3339), func(ctx context.Context, st *store) error {
+			_, err := s.removeByQuery(ctx, st.withLocale(locale), staleQuery(cutoff))
+			return err
+		})
+	}
+	return total, nil
+}
+
+func (s *server) countStale(ctx context.Context, cutoff time.Time) (int, error) {
+	query := staleQuery(cutoff)
+	if s.softDelete() {
+		query = notDeleted(query)
+	}
+	total := 0
+	for _, locale := range s.cfg.locales() {
+		n, err := s.st.withLocale(locale).countQuery(ctx, query)
+		if err != nil {
+			return total, err
+		}
+		total += n
+	}
+	return total, nil
+}
+
+func (s *server) gcLoop(ctx context.Context, interval, olderThan time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			deleted, err := s.gc(ctx, olderThan)
+			if err != nil {
+				log.Printf("비활성 키워드 정리 실패: %v", err)
+				continue
+			}
+			log.Printf("비활성 키워드 %d건 삭제 (기준 %s)", deleted, olderThan)
+		}
+	}
+}
+
+// parseAge는 time.ParseDuration 형식에 더해 "90d" 같은 일 단위를 받습니다.
+func parseAge(v string) (time.Duration, error) {
+	if days, ok := strings.CutSuffix(v, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil || n <= 0 {
+			return 0, fmt.Errorf("잘못된 기간: %q", v)
+		}
+		return time.Duration(n) * 24 * time.Hour, nil
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil || d <= 0 {
+		return 0, fmt.Errorf("잘못된 기간: %q", v)
+	}
+	return d, nil
+}
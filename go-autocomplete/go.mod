@@ -2,4 +2,13 @@ module autocomplete
 
 go 1.21
 
-require github.com/elastic/go-elasticsearch/v8 v8.12.0
+require (
+	github.com/aws/aws-sdk-go-v2 v1.24.1
+	github.com/aws/aws-sdk-go-v2/config v1.26.6
+	github.com/aws/aws-sdk-go-v2/service/s3 v1.48.1
+	github.com/elastic/go-elasticsearch/v8 v8.12.0
+	github.com/nats-io/nats.go v1.31.0
+	github.com/opensearch-project/opensearch-go/v2 v2.3.0
+	github.com/segmentio/kafka-go v0.4.47
+	golang.org/x/text v0.14.0
+)
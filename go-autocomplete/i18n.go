@@ -0,0 +1,78 @@
+package main
+
+import (
+	"embed"
+	"encoding/json"
+	"log"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const defaultLocale = "ko"
+
+//go:embed locales/*.json
+var localeFiles embed.FS
+
+var messages = loadMessages()
+
+func loadMessages() map[string]map[string]string {
+	entries, err := localeFiles.ReadDir("locales")
+	if err != nil {
+		log.Fatalf("메시지 카탈로그 읽기 실패: %v", err)
+	}
+	out := map[string]map[string]string{}
+	for _, e := range entries {
+		raw, err := localeFiles.ReadFile("locales/" + e.Name())
+		if err != nil {
+			log.Fatalf("메시지 카탈로그 읽기 실패 (%s): %v", e.Name(), err)
+		}
+		catalog := map[string]string{}
+		if err := json.Unmarshal(raw, &catalog); err != nil {
+			log.Fatalf("메시지 카탈로그 파싱 실패 (%s): %v", e.Name(), err)
+		}
+		out[strings.TrimSuffix(e.Name(), ".json")] = catalog
+	}
+	return out
+}
+
+// localize는 Accept-Language에 맞는 메시지를 돌려주고, 없으면 기본 로케일(ko), 그래도 없으면 키 자체를 씁니다.
+func localize(r *http.Request, key string) string {
+	if msg, ok := messages[requestLocale(r)][key]; ok {
+		return msg
+	}
+	if msg, ok := messages[defaultLocale][key]; ok {
+		return msg
+	}
+	return key
+}
+
+func requestLocale(r *http.Request) string {
+	type candidate struct {
+		tag string
+		q   float64
+	}
+	var candidates []candidate
+	for _, part := range strings.Split(r.Header.Get("Accept-Language"), ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		if tag == "" {
+			continue
+		}
+		q := 1.0
+		if v, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			if parsed, err := strconv.ParseFloat(v, 64); err == nil {
+				q = parsed
+			}
+		}
+		base, _, _ := strings.Cut(strings.ToLower(tag), "-")
+		candidates = append(candidates, candidate{tag: base, q: q})
+	}
+	sort.SliceStable(candidates, func(i, j int) bool { return candidates[i].q > candidates[j].q })
+	for _, c := range candidates {
+		if _, ok := messages[c.tag]; ok && c.q > 0 {
+			return c.tag
+		}
+	}
+	return defaultLocale
+}
@@ -0,0 +1,375 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/elastic/go-elasticsearch/v8/esapi"
+)
+
+const (
+	idempotencyIndex  = "autocomplete-idempotency"
+	idempotencyHeader = "Idempotency-Key"
+)
+
+// idempotency는 Idempotency-Key별 최초 처리 결과를 ES에 ttl 동안 보관하고,
+// 같은 키로 재전송된 요청에는 저장된 응답을 그대로 돌려줍니다. 기록이 maxKeys를 넘으면 정리 주기마다
+// 오래된 것부터 지웁니다.
+type idempotency struct {
+	st      *store
+	ttl     time.Duration
+	maxKeys int
+}
+
+type idempotencyRecord struct {
+	State       string    `json:"state"`
+	RequestHash string    `json:"request_hash"`
+	Status      int       `json:"status,omitempty"`
+	ContentType string    `json:"content_type,omitempty"`
+	Body        string    `json:"body,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+	ExpiresAt   time.Time `json:"expires_at"`
+}
+
+func newIdempotency(ctx context.Context, st *store, ttl time.Duration, maxKeys int) (*idempotency, error) {
+	if _, err := st.ensureNamedIndex(ctx, idempotencyIndex, idempotencyMapping); err != nil {
+		return nil, err
+	}
+	return &idempotency{st: st, ttl: ttl, maxKeys: maxKeys}, nil
+}
+
+func (idem *idempotency) wrap(next http.HandlerFunc) http.HandlerFunc {
+	if idem == nil {
+		return next
+	}
+	return func(w http.ResponseWriter, r *http.Request) {
+		key := r.Header.Get(idempotencyHeader)
+		if key == "" || r.Method != http.MethodPost {
+			next(w, r)
+			return
+		}
+
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			writeError(w, r, codeBadRequestBody)
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+		reqSum := sha256.Sum256(body)
+		reqHash := hex.EncodeToString(reqSum[:])
+		docSum := sha1.Sum([]byte(r.URL.Path + "\n" + key))
+		recordID := hex.EncodeToString(docSum[:])
+
+		ctx := r.Context()
+		now := time.Now()
+		reserved, err := idem.reserve(ctx, recordID, idempotencyRecord{
+			State:       "pending",
+			RequestHash: reqHash,
+			CreatedAt:   now,
+			ExpiresAt:   now.Add(idem.ttl),
+		})
+		if err != nil {
+			logf(ctx, "idempotency 키 예약 실패, 키 없이 처리: %v", err)
+			next(w, r)
+			return
+		}
+		if !reserved {
+			idem.replay(w, r, recordID, reqHash)
+			return
+		}
+
+		rec := &responseRecorder{ResponseWriter: w, status: http.StatusOK}
+		next(rec, r)
+		// 성공한 응답만 보관합니다. 429(요청 제한, 쓰기 큐 가득 참)나 5xx를 저장하면 재시도가 모두 같은 실패를
+		// 받게 되고, 4xx도 요청을 고쳐 같은 키로 다시 보내는 경우가 있어 키를 풀어 줍니다.
+		if rec.status < 200 || rec.status >= 300 {
+			idem.release(ctx, recordID)
+			return
+		}
+		err = idem.save(ctx, recordID, idempotencyRecord{
+			State:       "done",
+			RequestHash: reqHash,
+			Status:      rec.status,
+			ContentType: rec.Header().Get("Content-Type"),
+			Body:        rec.body.String(),
+			CreatedAt:   now,
+			ExpiresAt:   now.Add(idem.ttl),
+		})
+		if err != nil {
+			logf(ctx, "idempotency 결과 저장 실패: %v", err)
+		}
+	}
+}
+
+func (idem *idempotency) replay(w http.ResponseWriter, r *http.Request, recordID, reqHash string) {
+	rec, found, err := idem.load(r.Context(), recordID)
+	if err != nil {
+		logf(r.Context(), "idempotency 기록 조회 실패: %v", err)
+		writeError(w, r, codeInternal)
+		return
+	}
+	if !found {
+		writeError(w, r, codeIdempotencyInProgress)
+		return
+	}
+	if rec.RequestHash != reqHash {
+		writeError(w, r, codeIdempotencyKeyReused)
+		return
+	}
+	if rec.State != "done" {
+		writeError(w, r, codeIdempotencyInProgress)
+		return
+	}
+	if rec.ContentType != "" {
+		w.Header().Set("Content-Type", rec.ContentType)
+	}
+	w.Header().Set("Idempotent-Replayed", "true")
+	w.WriteHeader(rec.Status)
+	_, _ = io.WriteString(w, rec.Body)
+}
+
+func (idem *idempotency) reserve(ctx context.Context, recordID string, rec idempotencyRecord) (bool, error) {
+	body, err := json.Marshal(rec)
+	if err != nil {
+		return false, fmt.Errorf("idempotency 기록 직렬화 실패: %w", err)
+	}
+	res, err := esapi.CreateRequest{
+		Index:      idempotencyIndex,
+		DocumentID: recordID,
+		Body:       bytes.NewReader(body),
+		Refresh:    "true",
+	}.Do(ctx, idem.st.client)
+	if err != nil {
+		return false, fmt.Errorf("idempotency 예약 요청 실패: %w", err)
+	}
+	if res.StatusCode == http.StatusConflict {
+		// 기존 기록을 읽는 동안 쓰기 슬롯을 잡고 있지 않도록 본문을 먼저 닫습니다.
+		discard(res.Body)
+		existing, found, err := idem.load(ctx, recordID)
+		if err != nil {
+			return false, err
+		}
+		if found && time.Now().After(existing.ExpiresAt) {
+			return true, idem.save(ctx, recordID, rec)
+		}
+		return false, nil
+	}
+	defer discard(res.Body)
+	if res.IsError() {
+		return false, fmt.Errorf("idempotency 예약 응답 에러: %s", res.String())
+	}
+	return true, nil
+}
+
+func (idem *idempotency) load(ctx context.Context, recordID string) (idempotencyRecord, bool, error) {
+	res, err := esapi.GetRequest{Index: idempotencyIndex, DocumentID: recordID}.Do(ctx, idem.st.client)
+	if err != nil {
+		return idempotencyRecord{}, false, fmt.Errorf("idempotency 조회 요청 실패: %w", err)
+	}
+	defer discard(res.Body)
+	if res.StatusCode == http.StatusNotFound {
+		return idempotencyRecord{}, false, nil
+	}
+	if res.IsError() {
+		return idempotencyRecord{}, false, fmt.Errorf("idempotency 조회 응답 에러: %s", res.String())
+	}
+	var parsed struct {
+		Source idempotencyRecord `json:"_source"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&parsed); err != nil {
+		return idempotencyRecord{}, false, fmt.Errorf("응답 파싱 실패: %w", err)
+	}
+	return parsed.Source, true, nil
+}
+
+func (idem *idempotency) save(ctx context.Context, recordID string, rec idempotencyRecord) error {
+	body, err := json.Marshal(rec)
+	if err != nil {
+		return fmt.Errorf("idempotency 기록 직렬화 실패: %w", err)
+	}
+	res, err := esapi.IndexRequest{
+		Index:      idempotencyIndex,
+		DocumentID: recordID,
+		Body:       bytes.NewReader(body),
+		Refresh:    "true",
+	}.Do(ctx, idem.st.client)
+	if err != nil {
+		return fmt.Errorf("idempotency 저장 요청 실패: %w", err)
+	}
+	defer discard(res.Body)
+	if res.IsError() {
+		return fmt.Errorf("idempotency 저장 응답 에러: %s", res.String())
+	}
+	return nil
+}
+
+func (idem *idempotency) release(ctx context.Context, recordID string) {
+	res, err := esapi.DeleteRequest{Index: idempotencyIndex, DocumentID: recordID}.Do(ctx, idem.st.client)
+	if err != nil {
+		logf(ctx, "idempotency 키 해제 실패: %v", err)
+		return
+	}
+	discard(res.Body)
+}
+
+func (idem *idempotency) cleanupLoop(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := idem.cleanup(ctx); err != nil {
+				log.Printf("만료된 idempotency 기록 정리 실패: %v", err)
+			}
+		}
+	}
+}
+
+func (idem *idempotency) cleanup(ctx context.Context) error {
+	if err := idem.deleteWhere(ctx, map[string]interface{}{
+		"range": map[string]interface{}{
+			"expires_at": map[string]interface{}{"lt": "now"},
+		},
+	}); err != nil {
+		return err
+	}
+	if idem.maxKeys <= 0 {
+		return nil
+	}
+	return idem.trim(ctx)
+}
+
+// trim은 기록이 maxKeys를 넘으면 넘친 비율만큼 created_at이 오래된 기록을 지웁니다. 경계 시각은
+// percentiles 집계로 구하므로 근사값입니다.
+func (idem *idempotency) trim(ctx context.Context) error {
+	res, err := esapi.CountRequest{Index: []string{idempotencyIndex}}.Do(ctx, idem.st.client)
+	if err != nil {
+		return fmt.Errorf("idempotency 건수 요청 실패: %w", err)
+	}
+	defer discard(res.Body)
+	if res.IsError() {
+		return fmt.Errorf("idempotency 건수 응답 에러: %s", res.String())
+	}
+	var counted struct {
+		Count int `json:"count"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&counted); err != nil {
+		return fmt.Errorf("응답 파싱 실패: %w", err)
+	}
+	if counted.Count <= idem.maxKeys {
+		return nil
+	}
+	percent := float64(counted.Count-idem.maxKeys) / float64(counted.Count) * 100
+	cutoff, err := idem.createdPercentile(ctx, percent)
+	if err != nil || cutoff == "" {
+		return err
+	}
+	log.Printf("idempotency 기록 %d건이 상한 %d건을 넘어 %s 이전 기록을 지웁니다", counted.Count, idem.maxKeys, cutoff)
+	return idem.deleteWhere(ctx, map[string]interface{}{
+		"range": map[string]interface{}{
+			"created_at": map[string]interface{}{"lte": cutoff},
+		},
+	})
+}
+
+// createdPercentile은 created_at의 percent 백분위 시각을 돌려줍니다. 기록이 없으면 빈 문자열입니다.
+func (idem *idempotency) createdPercentile(ctx context.Context, percent float64) (string, error) {
+	body, err := json.Marshal(map[string]interface{}{
+		"size": 0,
+		"aggs": map[string]interface{}{
+			"cutoff": map[string]interface{}{"percentiles": map[string]interface{}{"field": "created_at", "percents": []float64{percent}}},
+		},
+	})
+	if err != nil {
+		return "", fmt.Errorf("쿼리 직렬화 실패: %w", err)
+	}
+	res, err := esapi.SearchRequest{
+		Index: []string{idempotencyIndex},
+		Body:  bytes.NewReader(body),
+	}.Do(ctx, idem.st.client)
+	if err != nil {
+		return "", fmt.Errorf("idempotency 집계 요청 실패: %w", err)
+	}
+	defer discard(res.Body)
+	if res.IsError() {
+		return "", fmt.Errorf("idempotency 집계 응답 에러: %s", res.String())
+	}
+	var parsed struct {
+		Aggregations struct {
+			Cutoff struct {
+				Values []struct {
+					ValueAsString string `json:"value_as_string"`
+				} `json:"values"`
+			} `json:"cutoff"`
+		} `json:"aggregations"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&parsed); err != nil {
+		return "", fmt.Errorf("응답 파싱 실패: %w", err)
+	}
+	if v := parsed.Aggregations.Cutoff.Values; len(v) > 0 {
+		return v[0].ValueAsString, nil
+	}
+	return "", nil
+}
+
+func (idem *idempotency) deleteWhere(ctx context.Context, query map[string]interface{}) error {
+	body, err := json.Marshal(map[string]interface{}{"query": query})
+	if err != nil {
+		return fmt.Errorf("쿼리 직렬화 실패: %w", err)
+	}
+	res, err := esapi.DeleteByQueryRequest{
+		Index:     []string{idempotencyIndex},
+		Body:      bytes.NewReader(body),
+		Conflicts: "proceed",
+	}.Do(ctx, idem.st.client)
+	if err != nil {
+		return fmt.Errorf("delete-by-query 요청 실패: %w", err)
+	}
+	defer discard(res.Body)
+	if res.IsError() {
+		return fmt.Errorf("delete-by-query 응답 에러: %s", res.String())
+	}
+	return nil
+}
+
+type responseRecorder struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (r *responseRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *responseRecorder) Write(p []byte) (int, error) {
+	r.body.Write(p)
+	return r.ResponseWriter.Write(p)
+}
+
+const idempotencyMapping = `
+{
+  "mappings": {
+    "properties": {
+      "state": { "type": "keyword" },
+      "request_hash": { "type": "keyword" },
+      "status": { "type": "integer" },
+      "content_type": { "type": "keyword", "index": false },
+      "body": { "type": "text", "index": false },
+      "created_at": { "type": "date" },
+      "expires_at": { "type": "date" }
+    }
+  }
+}`
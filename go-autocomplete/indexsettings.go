@@ -0,0 +1,114 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"text/template"
+
+	"github.com/elastic/go-elasticsearch/v8/esapi"
+)
+
+var indexMappingTemplate = template.Must(template.New("mapping").Parse(indexMapping))
+
+// indexSettings는 인덱스 생성 시점에만 적용되는 샤드/분석기 설정입니다.
+// 생성 때 매핑 _meta.index_settings에 함께 기록해 둡니다.
+type indexSettings struct {
+	Shards    int      `json:"number_of_shards"`
+	Replicas  int      `json:"number_of_replicas"`
+	MinGram   int      `json:"min_gram"`
+	MaxGram   int      `json:"max_gram"`
+	Tokenizer string   `json:"tokenizer"`
+	Channels  []string `json:"channels,omitempty"`
+
+	EmbeddingDims int `json:"embedding_dims,omitempty"`
+}
+
+func (is indexSettings) validate() error {
+	if is.Shards < 1 {
+		return fmt.Errorf("INDEX_SHARDS는 1 이상이어야 함: %d", is.Shards)
+	}
+	if is.Replicas < 0 {
+		return fmt.Errorf("INDEX_REPLICAS는 0 이상이어야 함: %d", is.Replicas)
+	}
+	if is.MinGram < 1 || is.MaxGram < is.MinGram {
+		return fmt.Errorf("edge_ngram 범위가 올바르지 않음: min_gram=%d, max_gram=%d", is.MinGram, is.MaxGram)
+	}
+	if is.EmbeddingDims < 0 || is.EmbeddingDims > 4096 {
+		return fmt.Errorf("EMBEDDING_DIMS는 4096 이하여야 함: %d", is.EmbeddingDims)
+	}
+	return nil
+}
+
+func (is indexSettings) render() (string, error) {
+	if err := is.validate(); err != nil {
+		return "", err
+	}
+	meta, err := json.Marshal(is)
+	if err != nil {
+		return "", fmt.Errorf("인덱스 설정 직렬화 실패: %w", err)
+	}
+	var buf bytes.Buffer
+	err = indexMappingTemplate.Execute(&buf, struct {
+		indexSettings
+		JSON string
+	}{is, string(meta)})
+	if err != nil {
+		return "", fmt.Errorf("매핑 템플릿 적용 실패: %w", err)
+	}
+	return buf.String(), nil
+}
+
+type settingDrift struct {
+	Index      string `json:"index"`
+	Setting    string `json:"setting"`
+	Configured int    `json:"configured"`
+	Actual     int    `json:"actual"`
+}
+
+// settingsDrift는 현재 설정값과 실제 인덱스 설정을 비교해 다른 항목을 돌려줍니다.
+// 샤드 수와 분석기는 기존 인덱스에 반영할 수 없으므로 재색인이 필요합니다.
+func (s *store) settingsDrift(ctx context.Context, name string) ([]settingDrift, error) {
+	flat := true
+	res, err := esapi.IndicesGetSettingsRequest{
+		Index:        []string{name},
+		FlatSettings: &flat,
+	}.Do(ctx, s.client)
+	if err != nil {
+		return nil, fmt.Errorf("인덱스 설정 조회 실패: %w", err)
+	}
+	defer discard(res.Body)
+	if res.IsError() {
+		return nil, fmt.Errorf("인덱스 설정 조회 응답 에러: %s", res.String())
+	}
+
+	var parsed map[string]struct {
+		Settings map[string]string `json:"settings"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&parsed); err != nil {
+		return nil, fmt.Errorf("응답 파싱 실패: %w", err)
+	}
+
+	wanted := []struct {
+		key   string
+		value int
+	}{
+		{"index.number_of_shards", s.settings.Shards},
+		{"index.number_of_replicas", s.settings.Replicas},
+		{"index.analysis.filter.autocomplete_filter.min_gram", s.settings.MinGram},
+		{"index.analysis.filter.autocomplete_filter.max_gram", s.settings.MaxGram},
+	}
+	var drift []settingDrift
+	for index, body := range parsed {
+		for _, w := range wanted {
+			actual, err := strconv.Atoi(body.Settings[w.key])
+			if err != nil || actual == w.value {
+				continue
+			}
+			drift = append(drift, settingDrift{Index: index, Setting: w.key, Configured: w.value, Actual: actual})
+		}
+	}
+	return drift, nil
+}
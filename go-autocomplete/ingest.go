@@ -0,0 +1,108 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+)
+
+// 이벤트 하나를 반영하는 최대 시도 횟수입니다.
+const cdcRetries = 3
+
+// errPoisonEvent는 다시 시도해도 반영할 수 없는 이벤트입니다.
+var errPoisonEvent = errors.New("처리할 수 없는 이벤트")
+
+// changeSource는 변경 이벤트 스트림입니다. handle이 에러를 돌려주면 소스의 방식대로 재전달하고,
+// 재시도를 다 쓰면 건너뜁니다. 어느 쪽이든 최소 한 번 처리입니다.
+type changeSource interface {
+	consume(ctx context.Context, handle func(ctx context.Context, value []byte) error)
+	String() string
+}
+
+// newChangeSource는 CDC_SOURCE에 맞는 구현을 만듭니다. 필수 설정이 비어 있으면 nil입니다.
+func newChangeSource(c config) (changeSource, error) {
+	switch c.CDCSource {
+	case "kafka":
+		if len(c.CDCBrokers) == 0 || c.CDCTopic == "" {
+			return nil, nil
+		}
+		return &kafkaSource{brokers: c.CDCBrokers, topic: c.CDCTopic, group: c.CDCGroup}, nil
+	case "nats":
+		if c.NATSURL == "" || c.CDCSubject == "" {
+			return nil, nil
+		}
+		return newJetStreamSource(c.NATSURL, c.CDCStream, c.CDCSubject, c.CDCDurable)
+	default:
+		return nil, fmt.Errorf("알 수 없는 CDC_SOURCE: %s", c.CDCSource)
+	}
+}
+
+func skipEvent(position string, err error) {
+	cdcSkipped.Add(1)
+	log.Printf("CDC 이벤트 건너뜀 (%s): %v", position, err)
+}
+
+// kafkaSource는 컨슈머 그룹으로 토픽을 읽습니다. 반영에 성공하거나 재시도를 다 쓴 뒤에만
+// 오프셋을 커밋하므로 재시작해도 이벤트를 잃지 않습니다.
+type kafkaSource struct {
+	brokers []string
+	topic   string
+	group   string
+}
+
+func (k *kafkaSource) String() string {
+	return fmt.Sprintf("kafka %s (group %s)", k.topic, k.group)
+}
+
+func (k *kafkaSource) consume(ctx context.Context, handle func(ctx context.Context, value []byte) error) {
+	reader := kafka.NewReader(kafka.ReaderConfig{
+		Brokers:  k.brokers,
+		GroupID:  k.group,
+		Topic:    k.topic,
+		MinBytes: 1,
+		MaxBytes: 10 << 20,
+	})
+	defer reader.Close()
+
+	for {
+		msg, err := reader.FetchMessage(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			log.Printf("CDC 메시지 수신 실패: %v", err)
+			time.Sleep(time.Second)
+			continue
+		}
+		// Debezium은 삭제 뒤에 값이 없는 tombstone 메시지를 보냅니다.
+		if len(msg.Value) > 0 {
+			k.handle(ctx, msg, handle)
+		}
+		if err := reader.CommitMessages(ctx, msg); err != nil && ctx.Err() == nil {
+			log.Printf("CDC 오프셋 커밋 실패: %v", err)
+		}
+	}
+}
+
+func (k *kafkaSource) handle(ctx context.Context, msg kafka.Message, handle func(ctx context.Context, value []byte) error) {
+	var err error
+	for attempt := 0; attempt < cdcRetries; attempt++ {
+		if attempt > 0 {
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(time.Duration(attempt) * time.Second):
+			}
+		}
+		if err = handle(ctx, msg.Value); err == nil || errors.Is(err, errPoisonEvent) {
+			break
+		}
+	}
+	if err != nil {
+		skipEvent(fmt.Sprintf("%s/%d@%d", msg.Topic, msg.Partition, msg.Offset), err)
+	}
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/nats-io/nats.go"
+)
+
+const (
+	jetStreamBatch   = 32
+	jetStreamWait    = 5 * time.Second
+	jetStreamAckWait = 30 * time.Second
+)
+
+// jetStreamSource는 durable pull 컨슈머로 스트림을 읽습니다. 반영에 성공하면 Ack, 실패하면
+// 지연을 두고 Nak해 서버가 다시 전달하게 하고, 재시도를 다 쓰거나 파싱할 수 없으면 Term합니다.
+type jetStreamSource struct {
+	nc      *nats.Conn
+	sub     *nats.Subscription
+	subject string
+	durable string
+}
+
+func newJetStreamSource(url, stream, subject, durable string) (*jetStreamSource, error) {
+	nc, err := nats.Connect(url, nats.Name("autocomplete-cdc"), nats.MaxReconnects(-1))
+	if err != nil {
+		return nil, fmt.Errorf("NATS 연결 실패: %w", err)
+	}
+	js, err := nc.JetStream()
+	if err != nil {
+		nc.Close()
+		return nil, fmt.Errorf("JetStream 컨텍스트 생성 실패: %w", err)
+	}
+	opts := []nats.SubOpt{nats.ManualAck(), nats.AckExplicit(), nats.AckWait(jetStreamAckWait), nats.MaxDeliver(cdcRetries)}
+	if stream != "" {
+		opts = append(opts, nats.BindStream(stream))
+	}
+	sub, err := js.PullSubscribe(subject, durable, opts...)
+	if err != nil {
+		nc.Close()
+		return nil, fmt.Errorf("JetStream 구독 실패: %w", err)
+	}
+	return &jetStreamSource{nc: nc, sub: sub, subject: subject, durable: durable}, nil
+}
+
+func (j *jetStreamSource) String() string {
+	return fmt.Sprintf("nats %s (durable %s)", j.subject, j.durable)
+}
+
+func (j *jetStreamSource) consume(ctx context.Context, handle func(ctx context.Context, value []byte) error) {
+	// durable 컨슈머는 서버에 남겨 두고 연결만 정리합니다.
+	defer j.nc.Drain()
+
+	for {
+		fetchCtx, cancel := context.WithTimeout(ctx, jetStreamWait)
+		msgs, err := j.sub.Fetch(jetStreamBatch, nats.Context(fetchCtx))
+		cancel()
+		if ctx.Err() != nil {
+			return
+		}
+		if err != nil && !errors.Is(err, context.DeadlineExceeded) && !errors.Is(err, nats.ErrTimeout) {
+			log.Printf("CDC 메시지 수신 실패: %v", err)
+			time.Sleep(time.Second)
+			continue
+		}
+		for _, msg := range msgs {
+			j.handle(ctx, msg, handle)
+		}
+	}
+}
+
+func (j *jetStreamSource) handle(ctx context.Context, msg *nats.Msg, handle func(ctx context.Context, value []byte) error) {
+	position := msg.Subject
+	var delivered uint64 = 1
+	if meta, err := msg.Metadata(); err == nil {
+		position = fmt.Sprintf("%s@%d", msg.Subject, meta.Sequence.Stream)
+		delivered = meta.NumDelivered
+	}
+	if len(msg.Data) == 0 {
+		msg.Ack()
+		return
+	}
+
+	err := handle(ctx, msg.Data)
+	switch {
+	case err == nil:
+		err = msg.Ack()
+	case errors.Is(err, errPoisonEvent) || delivered >= cdcRetries:
+		skipEvent(position, err)
+		err = msg.Term()
+	default:
+		err = msg.NakWithDelay(time.Duration(delivered) * time.Second)
+	}
+	if err != nil && ctx.Err() == nil {
+		log.Printf("CDC 메시지 확인 응답 실패 (%s): %v", position, err)
+	}
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"sync"
+	"time"
+)
+
+const (
+	jobRunning   = "running"
+	jobSucceeded = "succeeded"
+	jobFailed    = "failed"
+)
+
+// jobRegistry는 오래 걸리는 관리 작업(복원 등)의 진행 상황을 메모리에 보관합니다.
+type jobRegistry struct {
+	mu   sync.Mutex
+	jobs map[string]*job
+}
+
+type job struct {
+	ID         string                 `json:"id"`
+	Kind       string                 `json:"kind"`
+	Status     string                 `json:"status"`
+	Processed  int                    `json:"processed"`
+	Progress   float64                `json:"progress"`
+	Error      string                 `json:"error,omitempty"`
+	Result     map[string]interface{} `json:"result,omitempty"`
+	StartedAt  time.Time              `json:"started_at"`
+	FinishedAt *time.Time             `json:"finished_at,omitempty"`
+}
+
+func newJobRegistry() *jobRegistry {
+	return &jobRegistry{jobs: map[string]*job{}}
+}
+
+func (r *jobRegistry) start(kind string) string {
+	buf := make([]byte, 8)
+	_, _ = rand.Read(buf)
+	j := &job{
+		ID:        hex.EncodeToString(buf),
+		Kind:      kind,
+		Status:    jobRunning,
+		StartedAt: time.Now(),
+	}
+	r.mu.Lock()
+	r.jobs[j.ID] = j
+	r.mu.Unlock()
+	return j.ID
+}
+
+func (r *jobRegistry) progress(id string, processed int, fraction float64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if j, ok := r.jobs[id]; ok {
+		j.Processed = processed
+		j.Progress = fraction
+	}
+}
+
+func (r *jobRegistry) finish(id string, result map[string]interface{}, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	j, ok := r.jobs[id]
+	if !ok {
+		return
+	}
+	now := time.Now()
+	j.FinishedAt = &now
+	j.Result = result
+	if err != nil {
+		j.Status = jobFailed
+		j.Error = err.Error()
+		return
+	}
+	j.Status = jobSucceeded
+	j.Progress = 1
+}
+
+func (r *jobRegistry) get(id string) (job, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	j, ok := r.jobs[id]
+	if !ok {
+		return job{}, false
+	}
+	return *j, true
+}
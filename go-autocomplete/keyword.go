@@ -0,0 +1,237 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/elastic/go-elasticsearch/v8/esapi"
+)
+
+type keywordDocument struct {
+	ID            string                 `json:"id"`
+	Keyword       string                 `json:"keyword"`
+	Display       string                 `json:"display,omitempty"`
+	Inputs        []string               `json:"inputs"`
+	Weight        int                    `json:"weight"`
+	Meta          map[string]interface{} `json:"meta,omitempty"`
+	Status        string                 `json:"status"`
+	ExpiresAt     *time.Time             `json:"expires_at,omitempty"`
+	DeletedAt     *time.Time             `json:"deleted_at,omitempty"`
+	LastSeenAt    *time.Time             `json:"last_seen_at,omitempty"`
+	LastClickedAt *time.Time             `json:"last_clicked_at,omitempty"`
+	SeqNo         int                    `json:"seq_no"`
+	PrimaryTerm   int                    `json:"primary_term"`
+}
+
+type patchRequest struct {
+	Weight *int                   `json:"weight,omitempty" validate:"min=0"`
+	Meta   map[string]interface{} `json:"meta,omitempty"`
+}
+
+// keywordFromPath는 /keywords/{keyword}에서 키워드를 꺼냅니다. 키워드에 '/'가 들어갈 수 있으므로
+// 이스케이프된 경로에서 잘라낸 뒤 디코딩합니다.
+func keywordFromPath(r *http.Request, prefix, suffix string) (string, bool) {
+	raw := strings.TrimSuffix(strings.TrimPrefix(r.URL.EscapedPath(), prefix), suffix)
+	keyword, err := url.PathUnescape(raw)
+	if err != nil {
+		return "", false
+	}
+	keyword = canonicalKeyword(keyword)
+	return keyword, keyword != ""
+}
+
+func (s *server) handleKeyword(w http.ResponseWriter, r *http.Request) {
+	suffix := ""
+	if isRestorePath(r) {
+		suffix = restoreSuffix
+	}
+	keyword, ok := keywordFromPath(r, "/keywords/", suffix)
+	if !ok {
+		writeError(w, r, codeEmptyKeyword)
+		return
+	}
+	locale, ok := s.localeParam(w, r, r.URL.Query().Get("locale"))
+	if !ok {
+		return
+	}
+	if suffix != "" {
+		s.handleRestoreKeyword(w, r, locale, keyword)
+		return
+	}
+	switch r.Method {
+	case http.MethodGet:
+		s.handleGetKeyword(w, r, locale, keyword)
+	case http.MethodPatch:
+		s.handlePatch(w, r, locale, keyword)
+	default:
+		methodNotAllowed(w, r, "GET, PATCH")
+	}
+}
+
+func (s *server) handleGetKeyword(w http.ResponseWriter, r *http.Request, locale, keyword string) {
+	ctx, cancel := context.WithTimeout(r.Context(), s.live().SuggestTimeout)
+	defer cancel()
+	doc, err := s.st.withLocale(locale).getKeyword(ctx, keyword)
+	if errors.Is(err, errKeywordNotFound) {
+		writeError(w, r, codeKeywordNotFound)
+		return
+	}
+	if errors.Is(err, errESSaturated) {
+		w.Header().Set("Retry-After", "1")
+		writeError(w, r, codeServiceOverloaded)
+		return
+	}
+	if err != nil {
+		logf(r.Context(), "키워드 조회 실패: %v", err)
+		writeError(w, r, codeSearchFailed)
+		return
+	}
+	writeJSON(w, doc)
+}
+
+func (s *server) handlePatch(w http.ResponseWriter, r *http.Request, locale, keyword string) {
+	var req patchRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, r, codeBadRequestBody)
+		return
+	}
+	if req.Weight == nil && len(req.Meta) == 0 {
+		writeErrorDetails(w, r, codeBadRequestBody, map[string]interface{}{"required_one_of": []string{"weight", "meta"}})
+		return
+	}
+	if req.Weight != nil && *req.Weight < 0 {
+		writeErrorDetails(w, r, codeBadRequestBody, map[string]string{"field": "weight"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), s.live().UpsertTimeout)
+	defer cancel()
+	err := s.st.withLocale(locale).patchKeyword(ctx, keyword, req)
+	if errors.Is(err, errKeywordNotFound) {
+		writeError(w, r, codeKeywordNotFound)
+		return
+	}
+	if errors.Is(err, errESSaturated) {
+		w.Header().Set("Retry-After", "1")
+		writeError(w, r, codeTooManyRequests)
+		return
+	}
+	if err != nil {
+		logf(r.Context(), "patch 실패: %v", err)
+		writeError(w, r, codeUpsertFailed)
+		return
+	}
+	s.mir.enqueue("patch", keyword, func(ctx context.Context, st *store) error {
+		return st.withLocale(locale).patchKeyword(ctx, keyword, req)
+	})
+	s.hooks.publish(webhookEvent{Type: eventKeywordUpdated, Reason: "patch", Keyword: keyword, Locale: locale})
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// patchKeyword는 부분 업데이트로 가중치나 meta 필드만 바꿉니다. 문서 병합은 객체 단위로 재귀 적용되므로
+// suggest.input은 그대로 유지됩니다. 문서가 없으면 새로 만들지 않고 errKeywordNotFound를 돌려줍니다.
+func (s *store) patchKeyword(ctx context.Context, keyword string, req patchRequest) error {
+	doc := map[string]interface{}{}
+	if req.Weight != nil {
+		doc["weight"] = *req.Weight
+		doc["suggest"] = map[string]interface{}{"weight": *req.Weight}
+	}
+	if len(req.Meta) > 0 {
+		doc["meta"] = req.Meta
+	}
+	body, err := json.Marshal(map[string]interface{}{"doc": doc})
+	if err != nil {
+		return fmt.Errorf("payload 직렬화 실패: %w", err)
+	}
+	retries := updateRetries
+	res, err := esapi.UpdateRequest{
+		Index:           s.index,
+		DocumentID:      docID(keyword),
+		Body:            bytes.NewReader(body),
+		RetryOnConflict: &retries,
+	}.Do(ctx, s.client)
+	if err != nil {
+		return fmt.Errorf("patch 요청 실패: %w", err)
+	}
+	defer discard(res.Body)
+	if res.StatusCode == http.StatusNotFound {
+		return errKeywordNotFound
+	}
+	if res.IsError() {
+		return fmt.Errorf("patch 응답 에러: %s", res.String())
+	}
+	return nil
+}
+
+// getKeyword는 색인된 문서를 그대로 보여 줍니다. 추천어가 안 나올 때 실제로 무엇이 들어갔는지 확인하는 용도입니다.
+func (s *store) getKeyword(ctx context.Context, keyword string) (keywordDocument, error) {
+	id := docID(keyword)
+	res, err := esapi.GetRequest{Index: s.index, DocumentID: id}.Do(ctx, s.client)
+	if err != nil {
+		return keywordDocument{}, fmt.Errorf("문서 조회 요청 실패: %w", err)
+	}
+	defer discard(res.Body)
+	if res.StatusCode == http.StatusNotFound {
+		return keywordDocument{}, errKeywordNotFound
+	}
+	if res.IsError() {
+		return keywordDocument{}, fmt.Errorf("문서 조회 응답 에러: %s", res.String())
+	}
+	var parsed struct {
+		SeqNo       int `json:"_seq_no"`
+		PrimaryTerm int `json:"_primary_term"`
+		Source      struct {
+			Keyword string `json:"keyword"`
+			Display string `json:"display"`
+			Weight  *int   `json:"weight"`
+			Suggest struct {
+				Input  []string `json:"input"`
+				Weight int      `json:"weight"`
+			} `json:"suggest"`
+			TombstoneInput []string               `json:"tombstone_input"`
+			Meta           map[string]interface{} `json:"meta"`
+			ExpiresAt      *time.Time             `json:"expires_at"`
+			DeletedAt      *time.Time             `json:"deleted_at"`
+			LastSeenAt     *time.Time             `json:"last_seen_at"`
+			LastClickedAt  *time.Time             `json:"last_clicked_at"`
+		} `json:"_source"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&parsed); err != nil {
+		return keywordDocument{}, fmt.Errorf("응답 파싱 실패: %w", err)
+	}
+
+	src := parsed.Source
+	doc := keywordDocument{
+		ID:            id,
+		Keyword:       src.Keyword,
+		Display:       src.Display,
+		Inputs:        src.Suggest.Input,
+		Weight:        src.Suggest.Weight,
+		Meta:          src.Meta,
+		Status:        "active",
+		ExpiresAt:     src.ExpiresAt,
+		DeletedAt:     src.DeletedAt,
+		LastSeenAt:    src.LastSeenAt,
+		LastClickedAt: src.LastClickedAt,
+		SeqNo:         parsed.SeqNo,
+		PrimaryTerm:   parsed.PrimaryTerm,
+	}
+	if src.Weight != nil {
+		doc.Weight = *src.Weight
+	}
+	if src.ExpiresAt != nil && !time.Now().Before(*src.ExpiresAt) {
+		doc.Status = "expired"
+	}
+	if src.DeletedAt != nil {
+		doc.Status = "deleted"
+		doc.Inputs = src.TombstoneInput
+	}
+	return doc, nil
+}
@@ -0,0 +1,178 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/elastic/go-elasticsearch/v8/esapi"
+)
+
+// lifecyclePolicy는 쿼리 로그·감사 로그처럼 계속 쌓이는 분석용 인덱스의 보관 정책입니다.
+// Elasticsearch는 ILM, OpenSearch는 ISM으로 같은 정책(롤오버 후 보관 기간이 지나면 삭제)을 만듭니다.
+type lifecyclePolicy struct {
+	RolloverSize string
+	RolloverAge  time.Duration
+	DeleteAfter  time.Duration
+}
+
+func (c config) analyticsLifecycle() lifecyclePolicy {
+	return lifecyclePolicy{
+		RolloverSize: c.AnalyticsRolloverSize,
+		RolloverAge:  c.AnalyticsRolloverAge,
+		DeleteAfter:  c.AnalyticsRetention,
+	}
+}
+
+// esAge는 ES/OpenSearch 시간 단위 문자열로 바꿉니다. 둘 다 시간(h) 단위를 받습니다.
+func esAge(d time.Duration) string {
+	return fmt.Sprintf("%dh", int64(d.Hours()))
+}
+
+// ensureManagedIndex는 alias-000001 형태로 롤오버되는 인덱스를 준비합니다.
+// 정책과 인덱스 템플릿은 매번 갱신하고, 쓰기 별칭이 없을 때만 첫 인덱스를 만듭니다.
+func (s *store) ensureManagedIndex(ctx context.Context, alias, mappings string, p lifecyclePolicy) error {
+	policy := alias + "-policy"
+	if err := s.putLifecyclePolicy(ctx, policy, alias, p); err != nil {
+		return err
+	}
+
+	settings := map[string]interface{}{}
+	if s.backend == backendOpenSearch {
+		settings["plugins.index_state_management.rollover_alias"] = alias
+	} else {
+		settings["index.lifecycle.name"] = policy
+		settings["index.lifecycle.rollover_alias"] = alias
+	}
+	tmpl, err := json.Marshal(map[string]interface{}{
+		"index_patterns": []string{alias + "-*"},
+		"template": map[string]interface{}{
+			"settings": settings,
+			"mappings": json.RawMessage(mappings),
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("인덱스 템플릿 직렬화 실패: %w", err)
+	}
+	res, err := esapi.IndicesPutIndexTemplateRequest{Name: alias, Body: bytes.NewReader(tmpl)}.Do(ctx, s.client)
+	if err != nil {
+		return fmt.Errorf("인덱스 템플릿 등록 실패: %w", err)
+	}
+	defer discard(res.Body)
+	if res.IsError() {
+		return fmt.Errorf("인덱스 템플릿 등록 응답 에러: %s", res.String())
+	}
+
+	exists, err := esapi.IndicesExistsAliasRequest{Name: []string{alias}}.Do(ctx, s.client)
+	if err != nil {
+		return fmt.Errorf("별칭 확인 실패: %w", err)
+	}
+	defer discard(exists.Body)
+	if exists.StatusCode == http.StatusOK {
+		return nil
+	}
+	if exists.StatusCode != http.StatusNotFound {
+		return fmt.Errorf("별칭 확인 응답 코드: %d", exists.StatusCode)
+	}
+
+	first := fmt.Sprintf(`{"aliases":{%q:{"is_write_index":true}}}`, alias)
+	if err := s.createIndex(ctx, alias+"-000001", first); err != nil {
+		return err
+	}
+	log.Printf("수명 주기 관리 인덱스 생성: %s-000001 (정책 %s)", alias, policy)
+	return nil
+}
+
+func (s *store) putLifecyclePolicy(ctx context.Context, name, alias string, p lifecyclePolicy) error {
+	if s.backend == backendOpenSearch {
+		return s.putISMPolicy(ctx, name, alias, p)
+	}
+
+	rollover := map[string]interface{}{"max_age": esAge(p.RolloverAge)}
+	if p.RolloverSize != "" {
+		rollover["max_primary_shard_size"] = p.RolloverSize
+	}
+	body, err := json.Marshal(map[string]interface{}{
+		"policy": map[string]interface{}{
+			"phases": map[string]interface{}{
+				"hot": map[string]interface{}{
+					"actions": map[string]interface{}{"rollover": rollover},
+				},
+				"delete": map[string]interface{}{
+					"min_age": esAge(p.DeleteAfter),
+					"actions": map[string]interface{}{"delete": map[string]interface{}{}},
+				},
+			},
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("ILM 정책 직렬화 실패: %w", err)
+	}
+	res, err := esapi.ILMPutLifecycleRequest{Policy: name, Body: bytes.NewReader(body)}.Do(ctx, s.client)
+	if err != nil {
+		return fmt.Errorf("ILM 정책 등록 실패: %w", err)
+	}
+	defer discard(res.Body)
+	if res.IsError() {
+		return fmt.Errorf("ILM 정책 등록 응답 에러: %s", res.String())
+	}
+	return nil
+}
+
+// putISMPolicy는 OpenSearch ISM 정책을 만듭니다. esapi에 ISM 요청이 없어 Perform으로 직접 보내며,
+// 이미 있는 정책은 seq_no 없이 덮어쓸 수 없으므로 그대로 둡니다.
+func (s *store) putISMPolicy(ctx context.Context, name, alias string, p lifecyclePolicy) error {
+	rollover := map[string]interface{}{"min_index_age": esAge(p.RolloverAge)}
+	if p.RolloverSize != "" {
+		rollover["min_size"] = p.RolloverSize
+	}
+	body, err := json.Marshal(map[string]interface{}{
+		"policy": map[string]interface{}{
+			"description":   "autocomplete 분석 인덱스 보관 정책",
+			"default_state": "hot",
+			"states": []interface{}{
+				map[string]interface{}{
+					"name":    "hot",
+					"actions": []interface{}{map[string]interface{}{"rollover": rollover}},
+					"transitions": []interface{}{map[string]interface{}{
+						"state_name": "delete",
+						"conditions": map[string]interface{}{"min_index_age": esAge(p.DeleteAfter)},
+					}},
+				},
+				map[string]interface{}{
+					"name":        "delete",
+					"actions":     []interface{}{map[string]interface{}{"delete": map[string]interface{}{}}},
+					"transitions": []interface{}{},
+				},
+			},
+			"ism_template": []interface{}{map[string]interface{}{
+				"index_patterns": []string{alias + "-*"},
+				"priority":       100,
+			}},
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("ISM 정책 직렬화 실패: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, "/_plugins/_ism/policies/"+name, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("ISM 정책 요청 생성 실패: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	res, err := s.client.Perform(req)
+	if err != nil {
+		return fmt.Errorf("ISM 정책 등록 실패: %w", err)
+	}
+	defer discard(res.Body)
+	if res.StatusCode == http.StatusConflict {
+		return nil
+	}
+	if res.StatusCode >= 300 {
+		return fmt.Errorf("ISM 정책 등록 응답 코드: %d", res.StatusCode)
+	}
+	return nil
+}
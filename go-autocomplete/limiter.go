@@ -0,0 +1,104 @@
+package main
+
+import (
+	"errors"
+	"expvar"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/elastic/go-elasticsearch/v8/esapi"
+)
+
+var (
+	esWaitMillis = expvar.NewMap("es_semaphore_wait_ms_total")
+	esAcquired   = expvar.NewMap("es_semaphore_acquired_total")
+	esRejected   = expvar.NewMap("es_semaphore_rejected_total")
+
+	errESSaturated = errors.New("ES 동시 요청 한도 초과")
+)
+
+// limitedTransport는 ES로 나가는 동시 요청 수를 읽기/쓰기별 세마포어로 제한합니다.
+// maxWait 안에 슬롯을 얻지 못하면 errESSaturated로 즉시 실패해 장애를 증폭시키지 않습니다.
+type limitedTransport struct {
+	next   esapi.Transport
+	limits atomic.Pointer[semaphores]
+}
+
+type semaphores struct {
+	reads   chan struct{}
+	writes  chan struct{}
+	maxWait time.Duration
+}
+
+func newLimitedTransport(next esapi.Transport, maxReads, maxWrites int, maxWait time.Duration) *limitedTransport {
+	t := &limitedTransport{next: next}
+	t.resize(maxReads, maxWrites, maxWait)
+	return t
+}
+
+// resize는 한도를 바꿉니다. 진행 중인 요청은 획득한 이전 세마포어에 슬롯을 돌려주므로
+// 전환 직후 잠시 동안은 이전 요청과 새 요청을 합쳐 새 한도를 넘을 수 있습니다.
+func (t *limitedTransport) resize(maxReads, maxWrites int, maxWait time.Duration) {
+	t.limits.Store(&semaphores{
+		reads:   make(chan struct{}, maxReads),
+		writes:  make(chan struct{}, maxWrites),
+		maxWait: maxWait,
+	})
+}
+
+func (t *limitedTransport) Perform(req *http.Request) (*http.Response, error) {
+	l := t.limits.Load()
+	kind, sem := "write", l.writes
+	if isReadRequest(req) {
+		kind, sem = "read", l.reads
+	}
+
+	start := time.Now()
+	timer := time.NewTimer(l.maxWait)
+	defer timer.Stop()
+	select {
+	case sem <- struct{}{}:
+	case <-timer.C:
+		esRejected.Add(kind, 1)
+		return nil, errESSaturated
+	case <-req.Context().Done():
+		return nil, req.Context().Err()
+	}
+	esWaitMillis.Add(kind, time.Since(start).Milliseconds())
+	esAcquired.Add(kind, 1)
+
+	// 큰 검색·scroll·bulk 응답은 헤더 이후 본문을 읽는 동안에도 연결을 쓰므로 본문을 닫을 때 슬롯을 돌려줍니다.
+	res, err := t.next.Perform(req)
+	if err != nil || res == nil || res.Body == nil {
+		<-sem
+		return res, err
+	}
+	res.Body = &releasingBody{ReadCloser: res.Body, release: func() { <-sem }}
+	return res, nil
+}
+
+// releasingBody는 Close가 처음 불릴 때 세마포어 슬롯을 돌려줍니다. 호출자는 모두 discard로 본문을 닫습니다.
+type releasingBody struct {
+	io.ReadCloser
+	once    sync.Once
+	release func()
+}
+
+func (b *releasingBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.once.Do(b.release)
+	return err
+}
+
+func isReadRequest(req *http.Request) bool {
+	switch req.Method {
+	case http.MethodGet, http.MethodHead:
+		return true
+	}
+	path := req.URL.Path
+	return strings.HasSuffix(path, "/_search") || strings.HasSuffix(path, "/_count") || strings.HasPrefix(path, "/_search/scroll")
+}
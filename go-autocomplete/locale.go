@@ -0,0 +1,78 @@
+package main
+
+import (
+	"net/http"
+	"strings"
+)
+
+// localeTokenizers는 로케일별 인덱스의 토크나이저입니다. nori/kuromoji는 ES에 analysis-nori,
+// analysis-kuromoji 플러그인이 있어야 합니다. 목록에 없는 로케일은 standard를 씁니다.
+var localeTokenizers = map[string]string{
+	"ko": "nori_tokenizer",
+	"ja": "kuromoji_tokenizer",
+	"en": "standard",
+}
+
+// localeIndexName은 로케일별 인덱스 이름입니다. 기본 로케일("")은 기존 autocomplete 인덱스를 그대로 씁니다.
+func localeIndexName(locale string) string {
+	if locale == "" {
+		return indexName
+	}
+	return indexName + "-" + locale
+}
+
+// withLocale은 같은 클라이언트로 로케일 인덱스를 다루는 store를 돌려줍니다.
+func (s *store) withLocale(locale string) *store {
+	if locale == "" {
+		return s
+	}
+	ls := *s
+	ls.index = localeIndexName(locale)
+	ls.settings.Tokenizer = "standard"
+	if t, ok := localeTokenizers[locale]; ok {
+		ls.settings.Tokenizer = t
+	}
+	return &ls
+}
+
+// locales는 기본 로케일("")을 포함해 인덱스를 가진 로케일 목록입니다.
+func (c config) locales() []string {
+	out := []string{""}
+	for _, l := range c.ExtraLocales {
+		if l = strings.ToLower(l); l != c.DefaultLocale {
+			out = append(out, l)
+		}
+	}
+	return out
+}
+
+// localeName은 기본 로케일("")을 설정의 이름으로 바꿉니다. 로그처럼 로케일을 값으로 남길 때 씁니다.
+func (c config) localeName(locale string) string {
+	if locale == "" {
+		return c.DefaultLocale
+	}
+	return locale
+}
+
+// localeFromName은 localeName의 반대로, 설정에 있는 로케일 이름을 store에서 쓰는 형태로 바꿉니다.
+func (c config) localeFromName(name string) (string, bool) {
+	if name == "" || name == c.DefaultLocale {
+		return "", true
+	}
+	for _, l := range c.locales() {
+		if l == name {
+			return l, true
+		}
+	}
+	return "", false
+}
+
+// localeParam은 요청의 locale 값을 확인해 store에서 쓰는 형태로 바꿉니다.
+// 비어 있거나 기본 로케일이면 "", 설정에 없는 로케일이면 400을 쓰고 false를 돌려줍니다.
+func (s *server) localeParam(w http.ResponseWriter, r *http.Request, raw string) (string, bool) {
+	if locale, ok := s.cfg.localeFromName(strings.ToLower(strings.TrimSpace(raw))); ok {
+		return locale, true
+	}
+	invalidParameter(w, r, "locale")
+	return "", false
+}
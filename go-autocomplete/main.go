@@ -1,228 +1,284 @@
 package main
 
 import (
-	"bytes"
 	"context"
 	"crypto/sha1"
 	"encoding/hex"
 	"encoding/json"
 	"errors"
-	"fmt"
 	"io"
 	"log"
 	"net/http"
 	"os"
+	"os/signal"
 	"strings"
+	"syscall"
 	"time"
-
-	elastic "github.com/elastic/go-elasticsearch/v8"
-	"github.com/elastic/go-elasticsearch/v8/esapi"
 )
 
 const (
 	indexName     = "autocomplete"
 	defaultESHost = "http://localhost:9200"
+	suggestSize   = 10
 )
 
 type upsertRequest struct {
-	Keyword string                 `json:"keyword"`
-	Weight  int                    `json:"weight,omitempty"`
-	Meta    map[string]interface{} `json:"meta,omitempty"`
+	Keyword     string                 `json:"keyword" validate:"required"`
+	Display     string                 `json:"display,omitempty"`
+	Weight      int                    `json:"weight,omitempty"`
+	WeightDelta int                    `json:"weight_delta,omitempty"`
+	Meta        map[string]interface{} `json:"meta,omitempty"`
+	ExpiresAt   *time.Time             `json:"expires_at,omitempty"`
+	Romanize    *bool                  `json:"romanize,omitempty"`
+	Locale      string                 `json:"locale,omitempty"`
+	Channels    []string               `json:"channels,omitempty"`
 }
 
 type suggestResponse struct {
-	Suggestions []string `json:"suggestions"`
+	Suggestions     []string            `json:"suggestions"`
+	Corrected       bool                `json:"corrected,omitempty"`
+	CorrectedPrefix string              `json:"corrected_prefix,omitempty"`
+	Hint            *suggestHint        `json:"hint,omitempty"`
+	Products        []productSuggestion `json:"products,omitempty"`
+}
+
+// suggestHint는 ES를 조회하지 않고 빈 결과를 돌려준 이유입니다.
+type suggestHint struct {
+	Reason    string `json:"reason"`
+	MinLength int    `json:"min_length"`
 }
 
 func main() {
-	esURL := strings.TrimSpace(os.Getenv("ELASTICSEARCH_URL"))
-	if esURL == "" {
-		esURL = defaultESHost
+	cfgFile := newConfigFile(strings.TrimSpace(os.Getenv("CONFIG_FILE")))
+	if err := cfgFile.apply(); err != nil {
+		log.Fatalf("설정 파일 적용 실패: %v", err)
+	}
+	cfg := loadConfig()
+	if err := cfg.tunables().validate(); err != nil {
+		log.Fatalf("설정 오류: %v", err)
 	}
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGTERM, os.Interrupt)
+	defer stop()
 
-	es, err := elastic.NewClient(elastic.Config{
-		Addresses: []string{esURL},
-		Username:  os.Getenv("ELASTICSEARCH_USERNAME"),
-		Password:  os.Getenv("ELASTICSEARCH_PASSWORD"),
-	})
+	st, err := newStore(ctx, cfg)
 	if err != nil {
-		log.Fatalf("elasticsearch 초기화 실패: %v", err)
+		log.Fatalf("검색 백엔드 초기화 실패: %v", err)
 	}
-
-	ctx := context.Background()
-	if err := ensureIndex(ctx, es); err != nil {
-		log.Fatalf("인덱스 준비 실패: %v", err)
+	for _, locale := range cfg.locales() {
+		if err := st.withLocale(locale).ensureIndex(ctx); err != nil {
+			log.Fatalf("인덱스 준비 실패: %v", err)
+		}
 	}
 
-	mux := http.NewServeMux()
-	mux.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {
-		w.WriteHeader(http.StatusOK)
-		_, _ = w.Write([]byte("ok"))
-	})
-	mux.HandleFunc("/keywords", func(w http.ResponseWriter, r *http.Request) {
-		if r.Method != http.MethodPost {
-			http.Error(w, "POST로 요청하세요", http.StatusMethodNotAllowed)
-			return
-		}
-		var req upsertRequest
-		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-			http.Error(w, "잘못된 요청 본문", http.StatusBadRequest)
-			return
-		}
-		if err := upsertKeyword(ctx, es, req); err != nil {
-			log.Printf("upsert 실패: %v", err)
-			http.Error(w, "업서트 실패", http.StatusInternalServerError)
-			return
-		}
-		w.WriteHeader(http.StatusCreated)
-	})
-	mux.HandleFunc("/suggest", func(w http.ResponseWriter, r *http.Request) {
-		q := strings.TrimSpace(r.URL.Query().Get("q"))
-		if q == "" {
-			http.Error(w, "q 파라미터가 필요합니다", http.StatusBadRequest)
-			return
-		}
-		suggestions, err := suggest(ctx, es, q)
+	reads := newReadRouter(st, cfg.FailoverThreshold, cfg.FailoverCooldown)
+	for _, url := range cfg.ReplicaURLs {
+		replica, err := newStore(ctx, cfg.replica(url))
 		if err != nil {
-			log.Printf("suggest 실패: %v", err)
-			http.Error(w, "검색 실패", http.StatusInternalServerError)
-			return
+			log.Fatalf("복제본 클러스터 초기화 실패: %v", err)
 		}
-		writeJSON(w, suggestResponse{Suggestions: suggestions})
-	})
+		reads.addReplica(url, replica)
+		log.Printf("suggest 읽기 복제본 등록: %s", url)
+	}
 
-	port := os.Getenv("PORT")
-	if strings.TrimSpace(port) == "" {
-		port = "8080"
+	trie := &fallbackTrie{}
+	if cfg.FallbackTopN > 0 {
+		go trie.refreshLoop(ctx, st, cfg.FallbackTopN, cfg.FallbackRefresh)
 	}
-	srv := &http.Server{
-		Addr:              ":" + port,
-		Handler:           mux,
-		ReadHeaderTimeout: 3 * time.Second,
+
+	var qlog *queryLogger
+	if cfg.QueryLog {
+		if err := st.ensureManagedIndex(ctx, queryLogAlias, queryLogMapping, cfg.analyticsLifecycle()); err != nil {
+			log.Fatalf("쿼리 로그 인덱스 준비 실패: %v", err)
+		}
+		qlog = newQueryLogger(st, cfg.QueryLogQueueSize, 500, cfg.QueryLogFlush)
+		go qlog.run(ctx)
+		log.Printf("쿼리 로그 활성화: %s", queryLogAlias)
 	}
-	log.Printf("autocomplete API 시작: 포트 %s, ES %s", port, esURL)
-	if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
-		log.Fatalf("서버 종료: %v", err)
+
+	var shadow *shadowMirror
+	if cfg.ShadowIndex != "" {
+		shadowSt := st.withIndex(cfg.ShadowIndex, cfg.shadowSettings())
+		if err := shadowSt.ensureIndex(ctx); err != nil {
+			log.Fatalf("후보 인덱스 준비 실패: %v", err)
+		}
+		shadow = newShadowMirror(shadowSt, cfg.ShadowSampleRate, 1000)
+		go shadow.run(ctx)
+		log.Printf("후보 인덱스 비교 활성화: %s (표본 %.0f%%)", cfg.ShadowIndex, cfg.ShadowSampleRate*100)
 	}
-}
 
-func ensureIndex(ctx context.Context, es *elastic.Client) error {
-	res, err := es.Indices.Exists([]string{indexName}, es.Indices.Exists.WithContext(ctx))
-	if err != nil {
-		return fmt.Errorf("인덱스 확인 실패: %w", err)
+	var embed *embedder
+	if cfg.EmbeddingURL != "" {
+		if cfg.Backend != backendElasticsearch || cfg.EmbeddingDims < 1 {
+			log.Fatalf("EMBEDDING_URL은 SEARCH_BACKEND=elasticsearch와 EMBEDDING_DIMS가 필요합니다")
+		}
+		for _, locale := range cfg.locales() {
+			if err := st.withLocale(locale).ensureVectorField(ctx, cfg.EmbeddingDims); err != nil {
+				log.Fatalf("embedding 필드 준비 실패: %v", err)
+			}
+		}
+		embed = newEmbedder(cfg.EmbeddingURL, cfg.EmbeddingDims, cfg.EmbeddingBatch, cfg.EmbeddingTimeout)
+		log.Printf("의미 기반 추천 활성화: %s (%d차원)", cfg.EmbeddingURL, cfg.EmbeddingDims)
 	}
-	defer discard(res.Body)
-	if res.StatusCode == http.StatusOK {
-		return nil
+
+	var gens *generations
+	if cfg.ETagInterval > 0 {
+		// 캐시 항목이 generation을 기준으로 무효가 되므로 데우기 전에 한 번 채워 둡니다.
+		gens = &generations{}
+		gens.refresh(ctx, st, cfg.locales())
+		go gens.refreshLoop(ctx, st, cfg.locales(), cfg.ETagInterval)
 	}
-	if res.StatusCode != http.StatusNotFound {
-		return fmt.Errorf("인덱스 확인 응답 코드: %d", res.StatusCode)
+
+	var snap *snapshotter
+	if cfg.SnapshotBucket != "" {
+		snap, err = newSnapshotter(ctx, st, cfg)
+		if err != nil {
+			log.Fatalf("스냅샷 초기화 실패: %v", err)
+		}
+		if cfg.SnapshotInterval > 0 {
+			go snap.run(ctx, cfg.SnapshotInterval)
+		}
 	}
 
-	body := strings.NewReader(indexMapping)
-	createRes, err := es.Indices.Create(indexName, es.Indices.Create.WithBody(body), es.Indices.Create.WithContext(ctx))
-	if err != nil {
-		return fmt.Errorf("인덱스 생성 실패: %w", err)
+	var idem *idempotency
+	if cfg.IdempotencyTTL > 0 {
+		idem, err = newIdempotency(ctx, st, cfg.IdempotencyTTL, cfg.IdempotencyMaxKeys)
+		if err != nil {
+			log.Fatalf("idempotency 저장소 준비 실패: %v", err)
+		}
+		go idem.cleanupLoop(ctx, time.Hour)
 	}
-	defer discard(createRes.Body)
-	if createRes.IsError() {
-		return fmt.Errorf("인덱스 생성 응답 에러: %s", createRes.String())
+
+	var mir *mirror
+	if cfg.SecondaryURL != "" {
+		secondary, err := newStore(ctx, cfg.secondary())
+		if err != nil {
+			log.Fatalf("보조 클러스터 초기화 실패: %v", err)
+		}
+		for _, locale := range cfg.locales() {
+			if err := secondary.withLocale(locale).ensureIndex(ctx); err != nil {
+				log.Fatalf("보조 클러스터 인덱스 준비 실패: %v", err)
+			}
+		}
+		mir = newMirror(secondary, cfg.MirrorQueueSize, cfg.MirrorDeadLetters)
+		go mir.run(ctx)
+		log.Printf("보조 클러스터 이중 쓰기 활성화: %s %s", cfg.SecondaryBackend, cfg.SecondaryURL)
 	}
-	return nil
-}
 
-func upsertKeyword(ctx context.Context, es *elastic.Client, req upsertRequest) error {
-	keyword := strings.TrimSpace(req.Keyword)
-	if keyword == "" {
-		return errors.New("keyword가 비어 있음")
+	var hooks *notifier
+	if len(cfg.WebhookURLs) > 0 {
+		if cfg.WebhookSecret == "" {
+			log.Fatalf("WEBHOOK_URLS를 쓰려면 WEBHOOK_SECRET이 필요합니다")
+		}
+		hooks = newNotifier(cfg.WebhookURLs, cfg.WebhookSecret, cfg.WebhookQueueSize, cfg.WebhookRetries, cfg.WebhookTimeout)
+		go hooks.run(ctx)
+		log.Printf("웹훅 알림 활성화: %d개 URL", len(cfg.WebhookURLs))
 	}
-	if req.Weight == 0 {
-		req.Weight = 1
+
+	srv := &server{
+		cfg:    cfg,
+		bg:     ctx,
+		st:     st,
+		reads:  reads,
+		trie:   trie,
+		mir:    mir,
+		idem:   idem,
+		snap:   snap,
+		jobs:   newJobRegistry(),
+		hooks:  hooks,
+		gens:   gens,
+		dash:   newDashboard(),
+		qlog:   qlog,
+		shadow: shadow,
+		embed:  embed,
+		cache:  newSuggestCache(cfg.CacheSize, cfg.CacheTTL),
+
+		cfgFile: cfgFile,
 	}
-	if req.Meta == nil {
-		req.Meta = map[string]interface{}{}
+	go srv.reloadOnSignal(ctx)
+	// 쓰기 워커는 종료 신호가 아니라 HTTP 서버가 닫힌 뒤에 멈춰야 처리 중이던 요청의 enqueue를 놓치지 않습니다.
+	writerCtx, stopWriter := context.WithCancel(context.Background())
+	defer stopWriter()
+	if cfg.AsyncWrites {
+		srv.writer = newBulkWriter(st, cfg.WriteQueueSize, cfg.BulkBatchSize, cfg.BulkFlushInterval, cfg.BulkTimeout, srv.upsertWritten)
+		srv.writer.start(writerCtx, cfg.BulkWorkers)
 	}
 
-	doc := map[string]interface{}{
-		"keyword": keyword,
-		"suggest": map[string]interface{}{
-			"input":  []string{keyword},
-			"weight": req.Weight,
-		},
-		"meta": req.Meta,
+	if srv.cache != nil && qlog != nil && cfg.WarmTopN > 0 {
+		go srv.warmOnStart(ctx, cfg.WarmTopN, cfg.WarmTimeout)
+	} else {
+		srv.ready.Store(true)
 	}
-	payload := map[string]interface{}{
-		"doc":           doc,
-		"doc_as_upsert": true,
+
+	if embed != nil && cfg.EmbeddingInterval > 0 {
+		go srv.embedLoop(ctx, cfg.EmbeddingInterval)
 	}
-	body, err := json.Marshal(payload)
-	if err != nil {
-		return fmt.Errorf("payload 직렬화 실패: %w", err)
+
+	if cfg.ExpiryCleanupInterval > 0 {
+		go srv.expiryCleanupLoop(ctx, cfg.ExpiryCleanupInterval)
 	}
 
-	updateReq := esapi.UpdateRequest{
-		Index:      indexName,
-		DocumentID: docID(keyword),
-		Body:       bytes.NewReader(body),
+	if cfg.GCOlderThan > 0 && cfg.GCInterval > 0 {
+		go srv.gcLoop(ctx, cfg.GCInterval, cfg.GCOlderThan)
 	}
-	res, err := updateReq.Do(ctx, es)
+
+	cdc, err := newChangeSource(cfg)
 	if err != nil {
-		return fmt.Errorf("업서트 요청 실패: %w", err)
+		log.Fatalf("CDC 소스 초기화 실패: %v", err)
 	}
-	defer discard(res.Body)
-	if res.IsError() {
-		return fmt.Errorf("업서트 응답 에러: %s", res.String())
+	if cdc != nil {
+		go cdc.consume(ctx, srv.handleCDC)
+		log.Printf("Debezium CDC 구독: %s", cdc)
 	}
-	return nil
-}
 
-func suggest(ctx context.Context, es *elastic.Client, q string) ([]string, error) {
-	query := map[string]interface{}{
-		"suggest": map[string]interface{}{
-			"ac": map[string]interface{}{
-				"prefix": q,
-				"completion": map[string]interface{}{
-					"field":           "suggest",
-					"skip_duplicates": true,
-					"size":            10,
-				},
-			},
-		},
+	if cfg.SalesSourceURL != "" && cfg.SalesInterval > 0 {
+		go srv.salesLoop(ctx, cfg.SalesInterval)
 	}
-	body, err := json.Marshal(query)
-	if err != nil {
-		return nil, fmt.Errorf("쿼리 직렬화 실패: %w", err)
+
+	if cfg.DebugAddr != "off" {
+		dbg, err := debugServer(cfg.DebugAddr, withRequestID(srv.adminRoutes()))
+		if err != nil {
+			log.Fatalf("디버그 서버 설정 실패: %v", err)
+		}
+		go func() {
+			log.Printf("디버그 서버 시작: %s", cfg.DebugAddr)
+			if err := dbg.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+				log.Printf("디버그 서버 종료: %v", err)
+			}
+		}()
 	}
-	res, err := es.Search(
-		es.Search.WithContext(ctx),
-		es.Search.WithIndex(indexName),
-		es.Search.WithBody(bytes.NewReader(body)),
-	)
-	if err != nil {
-		return nil, fmt.Errorf("검색 요청 실패: %w", err)
+
+	httpSrv := &http.Server{
+		Addr:              ":" + cfg.Port,
+		Handler:           withRequestID(srv.routes()),
+		ReadHeaderTimeout: 3 * time.Second,
+		ReadTimeout:       cfg.ReadTimeout,
+		WriteTimeout:      cfg.WriteTimeout,
+		IdleTimeout:       cfg.IdleTimeout,
 	}
-	defer discard(res.Body)
-	if res.IsError() {
-		return nil, fmt.Errorf("검색 응답 에러: %s", res.String())
+	log.Printf("autocomplete API 시작: 포트 %s, %s %s", cfg.Port, cfg.Backend, cfg.ESURL)
+	serveErr := make(chan error, 1)
+	go func() { serveErr <- httpSrv.ListenAndServe() }()
+	select {
+	case err := <-serveErr:
+		if err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Fatalf("서버 종료: %v", err)
+		}
+	case <-ctx.Done():
 	}
 
-	var parsed struct {
-		Suggest map[string][]struct {
-			Options []struct {
-				Text string `json:"text"`
-			} `json:"options"`
-		} `json:"suggest"`
-	}
-	if err := json.NewDecoder(res.Body).Decode(&parsed); err != nil {
-		return nil, fmt.Errorf("응답 파싱 실패: %w", err)
+	log.Printf("종료 신호 수신, 처리 중인 요청 대기 (최대 %s)", cfg.ShutdownTimeout)
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.ShutdownTimeout)
+	defer cancel()
+	if err := httpSrv.Shutdown(shutdownCtx); err != nil {
+		log.Printf("HTTP 서버 종료 대기 초과: %v", err)
 	}
-	var out []string
-	for _, bucket := range parsed.Suggest["ac"] {
-		for _, opt := range bucket.Options {
-			out = append(out, opt.Text)
-		}
+	if srv.writer != nil {
+		pending := len(srv.writer.queue)
+		stopWriter()
+		srv.writer.wait()
+		log.Printf("비동기 쓰기 큐 반영 완료 (남은 요청 %d건)", pending)
 	}
-	return out, nil
+	log.Printf("autocomplete API 종료")
 }
 
 func writeJSON(w http.ResponseWriter, payload interface{}) {
@@ -233,49 +289,20 @@ func writeJSON(w http.ResponseWriter, payload interface{}) {
 	}
 }
 
+func writeJSONStatus(w http.ResponseWriter, status int, payload interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(payload); err != nil {
+		log.Printf("응답 직렬화 실패: %v", err)
+	}
+}
+
 func discard(body io.ReadCloser) {
 	_, _ = io.Copy(io.Discard, body)
 	_ = body.Close()
 }
 
 func docID(keyword string) string {
-	normalized := strings.ToLower(strings.TrimSpace(keyword))
-	sum := sha1.Sum([]byte(normalized))
+	sum := sha1.Sum([]byte(matchKey(keyword)))
 	return hex.EncodeToString(sum[:])
 }
-
-const indexMapping = `
-{
-  "settings": {
-    "analysis": {
-      "filter": {
-        "autocomplete_filter": {
-          "type": "edge_ngram",
-          "min_gram": 1,
-          "max_gram": 20
-        }
-      },
-      "analyzer": {
-        "autocomplete": {
-          "type": "custom",
-          "tokenizer": "standard",
-          "filter": [
-            "lowercase",
-            "autocomplete_filter"
-          ]
-        }
-      }
-    }
-  },
-  "mappings": {
-    "properties": {
-      "keyword": { "type": "keyword" },
-      "suggest": {
-        "type": "completion",
-        "analyzer": "autocomplete",
-        "preserve_separators": true
-      },
-      "meta": { "type": "object", "enabled": true }
-    }
-  }
-}`
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+)
+
+// mirror는 기본 클러스터에 성공한 쓰기를 보조 클러스터에 비동기로 재적용합니다.
+// 실패하거나 큐가 가득 찬 작업은 dead-letter 버퍼에 남겨 재처리할 수 있게 합니다.
+type mirror struct {
+	target *store
+	queue  chan mirrorOp
+
+	mu          sync.Mutex
+	deadLetters []mirrorOp
+	maxDead     int
+}
+
+type mirrorOp struct {
+	Kind     string    `json:"kind"`
+	Target   string    `json:"target"`
+	Error    string    `json:"error,omitempty"`
+	FailedAt time.Time `json:"failed_at,omitempty"`
+
+	apply func(ctx context.Context, s *store) error
+}
+
+func newMirror(target *store, queueSize, maxDead int) *mirror {
+	return &mirror{
+		target:  target,
+		queue:   make(chan mirrorOp, queueSize),
+		maxDead: maxDead,
+	}
+}
+
+func (m *mirror) run(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case op := <-m.queue:
+			if err := op.apply(ctx, m.target); err != nil {
+				log.Printf("보조 클러스터 반영 실패 (%s %s): %v", op.Kind, op.Target, err)
+				op.Error = err.Error()
+				op.FailedAt = time.Now()
+				m.bury(op)
+			}
+		}
+	}
+}
+
+func (m *mirror) enqueue(kind, target string, apply func(ctx context.Context, s *store) error) {
+	if m == nil {
+		return
+	}
+	op := mirrorOp{Kind: kind, Target: target, apply: apply}
+	select {
+	case m.queue <- op:
+	default:
+		op.Error = "미러 큐가 가득 참"
+		op.FailedAt = time.Now()
+		m.bury(op)
+	}
+}
+
+func (m *mirror) bury(op mirrorOp) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if len(m.deadLetters) >= m.maxDead {
+		log.Printf("dead-letter 버퍼 초과, 가장 오래된 항목 폐기: %s %s", m.deadLetters[0].Kind, m.deadLetters[0].Target)
+		m.deadLetters = m.deadLetters[1:]
+	}
+	m.deadLetters = append(m.deadLetters, op)
+}
+
+func (m *mirror) deadLetterSnapshot() []mirrorOp {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	out := make([]mirrorOp, len(m.deadLetters))
+	copy(out, m.deadLetters)
+	return out
+}
+
+func (m *mirror) replay() int {
+	m.mu.Lock()
+	ops := m.deadLetters
+	m.deadLetters = nil
+	m.mu.Unlock()
+
+	for _, op := range ops {
+		m.enqueue(op.Kind, op.Target, op.apply)
+	}
+	return len(ops)
+}
@@ -0,0 +1,263 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// apiOperation은 OpenAPI 문서에 싣는 엔드포인트 하나입니다. 요청/응답 스키마는 핸들러가 실제로
+// 디코딩/인코딩하는 구조체에서 만들므로, 필드를 바꾸면 문서도 따라 바뀝니다.
+type apiOperation struct {
+	Method   string
+	Path     string
+	Summary  string
+	Params   []apiParam
+	Request  interface{}
+	Status   int
+	Response interface{}
+	Errors   []string
+}
+
+type apiParam struct {
+	Name     string
+	In       string
+	Required bool
+	Summary  string
+}
+
+var (
+	localeQuery = apiParam{Name: "locale", In: "query", Summary: "로케일 (생략 시 DEFAULT_LOCALE)"}
+	keywordPath = apiParam{Name: "keyword", In: "path", Required: true, Summary: "URL 인코딩한 키워드"}
+)
+
+var apiOperations = []apiOperation{
+	{Method: http.MethodPost, Path: "/keywords", Summary: "키워드 업서트", Request: upsertRequest{},
+		Params: []apiParam{{Name: "Idempotency-Key", In: "header", Summary: "재시도 중복 방지 키"}},
+		Status: http.StatusCreated,
+		Errors: []string{codeBadRequestBody, codeEmptyKeyword, codeInvalidCharacters, codeInvalidParameter, codeWriteQueueFull, codeTooManyRequests, codeUpstreamTimeout, codeUpsertFailed, codeIdempotencyInProgress, codeIdempotencyKeyReused}},
+	{Method: http.MethodGet, Path: "/keywords/{keyword}", Summary: "키워드 문서 조회", Params: []apiParam{keywordPath, localeQuery},
+		Status: http.StatusOK, Response: keywordDocument{},
+		Errors: []string{codeEmptyKeyword, codeInvalidParameter, codeKeywordNotFound, codeServiceOverloaded, codeSearchFailed}},
+	{Method: http.MethodPatch, Path: "/keywords/{keyword}", Summary: "가중치/meta 부분 수정", Params: []apiParam{keywordPath, localeQuery},
+		Request: patchRequest{}, Status: http.StatusNoContent,
+		Errors: []string{codeBadRequestBody, codeEmptyKeyword, codeInvalidParameter, codeKeywordNotFound, codeTooManyRequests, codeUpsertFailed}},
+	{Method: http.MethodPost, Path: "/keywords/{keyword}/restore", Summary: "소프트 삭제된 키워드 복원", Params: []apiParam{keywordPath, localeQuery},
+		Status: http.StatusNoContent, Errors: []string{codeEmptyKeyword, codeInvalidParameter, codeKeywordNotFound, codeUpsertFailed}},
+	{Method: http.MethodPost, Path: "/keywords/delete", Summary: "키워드/문서 ID 목록 일괄 삭제", Request: batchDeleteRequest{},
+		Status: http.StatusOK, Errors: []string{codeBadRequestBody, codeEmptyKeyword, codeInvalidParameter, codeDeleteFailed}},
+	{Method: http.MethodGet, Path: "/suggest", Summary: "접두어 자동완성",
+		Params: []apiParam{{Name: "q", In: "query", Required: true, Summary: "입력 중인 접두어"}, localeQuery,
+			{Name: "channel", In: "query", Summary: "채널 (SUGGEST_CHANNELS 중 하나)"},
+			{Name: "include", In: "query", Summary: "products면 상품 추천을 함께 반환"},
+			{Name: "mode", In: "query", Summary: "prefix(기본) 또는 semantic (EMBEDDING_URL 설정 시 kNN 의미 기반 추천)"},
+			{Name: "fields", In: "query", Summary: "text, score, keyword, meta, meta.<키> 중 쉼표로 고른 필드. 지정하면 suggestions가 객체 배열"},
+			{Name: "If-None-Match", In: "header", Summary: "이전 응답의 ETag"}},
+		Status: http.StatusOK, Response: suggestResponse{},
+		Errors: []string{codeMissingParameter, codeInvalidCharacters, codeInvalidParameter, codeServiceOverloaded, codeUpstreamTimeout, codeSearchFailed}},
+	{Method: http.MethodPost, Path: "/suggest/click", Summary: "추천어 클릭 기록", Request: clickRequest{}, Status: http.StatusNoContent,
+		Errors: []string{codeBadRequestBody, codeEmptyKeyword, codeInvalidCharacters, codeInvalidParameter, codeKeywordNotFound, codeUpsertFailed}},
+	{Method: http.MethodPost, Path: "/admin/gc", Summary: "비활성 키워드 정리",
+		Params: []apiParam{{Name: "older_than", In: "query", Summary: "예: 90d"}, {Name: "dry_run", In: "query", Summary: "true면 건수만 집계"}},
+		Status: http.StatusOK, Errors: []string{codeInvalidParameter, codeMissingParameter, codeGCFailed}},
+	{Method: http.MethodDelete, Path: "/admin/keywords", Summary: "접두어/쿼리 일괄 삭제",
+		Params: []apiParam{{Name: "prefix", In: "query"}, localeQuery, {Name: "dry_run", In: "query", Summary: "false일 때만 삭제"},
+			{Name: "expected_count", In: "query", Summary: "dry-run에서 받은 matched"}},
+		Status: http.StatusOK, Errors: []string{codeBadRequestBody, codeMissingParameter, codeInvalidParameter, codeDeleteCountChanged, codeDeleteFailed}},
+	{Method: http.MethodGet, Path: "/admin/stats", Summary: "인덱스/수집 통계", Status: http.StatusOK, Errors: []string{codeSearchFailed}},
+	{Method: http.MethodGet, Path: "/admin/dashboard", Summary: "운영 대시보드 집계 (이 파드 기준)",
+		Params: []apiParam{{Name: "window", In: "query", Summary: "집계 구간, 1m~24h (기본 1h)"}, {Name: "top", In: "query", Summary: "상위 항목 수 (기본 20, 최대 100)"}},
+		Status: http.StatusOK, Errors: []string{codeInvalidParameter}},
+	{Method: http.MethodGet, Path: "/analytics/queries", Summary: "쿼리 로그 시간대별 집계 (QUERY_LOG=true 시)",
+		Params: []apiParam{{Name: "from", In: "query", Summary: "RFC3339, 기본 to-24h"}, {Name: "to", In: "query", Summary: "RFC3339, 기본 현재"},
+			{Name: "interval", In: "query", Summary: "버킷 간격, 예: 15m, 1h, 1d (기본 1h)"}, localeQuery},
+		Status: http.StatusOK, Errors: []string{codeInvalidParameter, codeSearchFailed}},
+	{Method: http.MethodGet, Path: "/admin/bulk-mode", Summary: "대량 적재 모드 상태", Status: http.StatusOK},
+	{Method: http.MethodPost, Path: "/admin/bulk-mode", Summary: "대량 적재 모드 전환", Request: bulkModeRequest{}, Status: http.StatusOK,
+		Errors: []string{codeBadRequestBody, codeIndexSettingsFailed}},
+	{Method: http.MethodPost, Path: "/admin/reload", Summary: "설정 다시 읽기 (재시작 없이 바꿀 수 있는 값만 적용)", Status: http.StatusOK,
+		Response: reloadResult{}, Errors: []string{codeReloadFailed}},
+	{Method: http.MethodPost, Path: "/admin/warm", Summary: "쿼리 로그 상위 접두어로 suggest 캐시 데우기 (QUERY_LOG=true 시)",
+		Params: []apiParam{{Name: "top", In: "query", Summary: "접두어 수, 1~10000 (기본 SUGGEST_WARM_TOP_N)"}},
+		Status: http.StatusAccepted, Errors: []string{codeInvalidParameter}},
+	{Method: http.MethodGet, Path: "/admin/shadow", Summary: "후보 인덱스 결과 비교 통계 (SHADOW_INDEX 설정 시)", Status: http.StatusOK},
+	{Method: http.MethodPost, Path: "/admin/shadow/rebuild", Summary: "후보 인덱스 재생성 후 재색인 (SHADOW_INDEX 설정 시)", Status: http.StatusAccepted,
+		Errors: []string{codeIndexSettingsFailed}},
+	{Method: http.MethodGet, Path: "/admin/jobs/{id}", Summary: "비동기 작업 상태",
+		Params: []apiParam{{Name: "id", In: "path", Required: true}}, Status: http.StatusOK, Response: job{}, Errors: []string{codeJobNotFound}},
+	{Method: http.MethodPost, Path: "/admin/sales/sync", Summary: "판매 기반 가중치 재계산 (SALES_SOURCE_URL 설정 시)", Status: http.StatusAccepted},
+	{Method: http.MethodGet, Path: "/admin/mirror/dead-letters", Summary: "보조 클러스터 반영 실패 목록 (미러링 설정 시)", Status: http.StatusOK},
+	{Method: http.MethodPost, Path: "/admin/mirror/replay", Summary: "dead-letter 재처리 (미러링 설정 시)", Status: http.StatusOK},
+	{Method: http.MethodPost, Path: "/admin/snapshot", Summary: "S3 스냅샷 (SNAPSHOT_BUCKET 설정 시)", Status: http.StatusOK,
+		Errors: []string{codeSnapshotInProgress, codeSnapshotFailed}},
+	{Method: http.MethodPost, Path: "/admin/restore", Summary: "스냅샷 복원 (SNAPSHOT_BUCKET 설정 시)",
+		Params: []apiParam{{Name: "snapshot", In: "query", Required: true, Summary: "S3 객체 키"}}, Status: http.StatusAccepted,
+		Errors: []string{codeMissingParameter, codeRestoreInProgress}},
+}
+
+// buildOpenAPI는 apiOperations로 OpenAPI 3.0 문서를 만듭니다.
+func buildOpenAPI(ops []apiOperation) map[string]interface{} {
+	schemas := map[string]interface{}{}
+	errorRef := schemaOf(reflect.TypeOf(errorResponse{}), schemas)
+
+	paths := map[string]map[string]interface{}{}
+	for _, op := range ops {
+		operation := map[string]interface{}{"summary": op.Summary}
+		if len(op.Params) > 0 {
+			params := make([]map[string]interface{}, 0, len(op.Params))
+			for _, p := range op.Params {
+				param := map[string]interface{}{"name": p.Name, "in": p.In, "required": p.Required, "schema": map[string]string{"type": "string"}}
+				if p.Summary != "" {
+					param["description"] = p.Summary
+				}
+				params = append(params, param)
+			}
+			operation["parameters"] = params
+		}
+		if op.Request != nil {
+			operation["requestBody"] = map[string]interface{}{
+				"required": true,
+				"content":  map[string]interface{}{"application/json": map[string]interface{}{"schema": schemaOf(reflect.TypeOf(op.Request), schemas)}},
+			}
+		}
+
+		errs := op.Errors
+		if strings.HasPrefix(op.Path, adminPathPrefix) {
+			operation["security"] = []map[string][]string{{"adminToken": {}}}
+			errs = append([]string{codeUnauthorized}, errs...)
+		}
+
+		responses := map[string]interface{}{}
+		success := map[string]interface{}{"description": http.StatusText(op.Status)}
+		if op.Status != http.StatusNoContent {
+			var schema interface{} = map[string]string{"type": "object"}
+			if op.Response != nil {
+				schema = schemaOf(reflect.TypeOf(op.Response), schemas)
+			}
+			success["content"] = map[string]interface{}{"application/json": map[string]interface{}{"schema": schema}}
+		}
+		responses[strconv.Itoa(op.Status)] = success
+		if op.Method == http.MethodGet && op.Path == "/suggest" {
+			responses[strconv.Itoa(http.StatusNotModified)] = map[string]interface{}{"description": http.StatusText(http.StatusNotModified)}
+		}
+		// 같은 상태를 쓰는 오류 코드는 설명에 모아 적습니다.
+		byStatus := map[int][]string{}
+		for _, code := range append([]string{codeMethodNotAllowed}, errs...) {
+			byStatus[errorStatus[code]] = append(byStatus[errorStatus[code]], code)
+		}
+		for status, codes := range byStatus {
+			responses[strconv.Itoa(status)] = map[string]interface{}{
+				"description": strings.Join(codes, ", "),
+				"content":     map[string]interface{}{"application/json": map[string]interface{}{"schema": errorRef}},
+			}
+		}
+		operation["responses"] = responses
+
+		if paths[op.Path] == nil {
+			paths[op.Path] = map[string]interface{}{}
+		}
+		paths[op.Path][strings.ToLower(op.Method)] = operation
+	}
+
+	return map[string]interface{}{
+		"openapi": "3.0.3",
+		"info":    map[string]string{"title": "autocomplete", "version": "1"},
+		"paths":   paths,
+		"components": map[string]interface{}{
+			"schemas":         schemas,
+			"securitySchemes": map[string]interface{}{"adminToken": map[string]string{"type": "http", "scheme": "bearer"}},
+		},
+	}
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// schemaOf는 Go 타입을 JSON 스키마로 옮깁니다. 이름 있는 구조체는 components에 등록하고 참조를 돌려줍니다.
+// json 태그를 따르고, validate 태그의 required, min=N을 스키마 제약으로 옮깁니다.
+func schemaOf(t reflect.Type, schemas map[string]interface{}) map[string]interface{} {
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	switch {
+	case t == timeType:
+		return map[string]interface{}{"type": "string", "format": "date-time"}
+	case t.Kind() == reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case t.Kind() == reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case t.Kind() >= reflect.Int && t.Kind() <= reflect.Uint64:
+		return map[string]interface{}{"type": "integer"}
+	case t.Kind() == reflect.Float32 || t.Kind() == reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	case t.Kind() == reflect.Slice:
+		return map[string]interface{}{"type": "array", "items": schemaOf(t.Elem(), schemas)}
+	case t.Kind() == reflect.Map:
+		return map[string]interface{}{"type": "object", "additionalProperties": schemaOf(t.Elem(), schemas)}
+	case t.Kind() == reflect.Interface:
+		return map[string]interface{}{}
+	case t.Kind() != reflect.Struct:
+		return map[string]interface{}{}
+	}
+
+	name := t.Name()
+	if name != "" {
+		if _, ok := schemas[name]; !ok {
+			schemas[name] = nil // 재귀 참조 대비
+			schemas[name] = structSchema(t, schemas)
+		}
+		return map[string]interface{}{"$ref": "#/components/schemas/" + name}
+	}
+	return structSchema(t, schemas)
+}
+
+func structSchema(t reflect.Type, schemas map[string]interface{}) map[string]interface{} {
+	props := map[string]interface{}{}
+	var required []string
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+		name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		prop := schemaOf(f.Type, schemas)
+		for _, rule := range strings.Split(f.Tag.Get("validate"), ",") {
+			switch {
+			case rule == "required":
+				required = append(required, name)
+			case strings.HasPrefix(rule, "min="):
+				if n, err := strconv.Atoi(strings.TrimPrefix(rule, "min=")); err == nil {
+					prop["minimum"] = n
+				}
+			}
+		}
+		props[name] = prop
+	}
+	out := map[string]interface{}{"type": "object", "properties": props}
+	if len(required) > 0 {
+		sort.Strings(required)
+		out["required"] = required
+	}
+	return out
+}
+
+// handleOpenAPI는 시작 시 한 번 만든 문서를 그대로 내려줍니다.
+func handleOpenAPI() http.HandlerFunc {
+	body, err := json.Marshal(buildOpenAPI(apiOperations))
+	if err != nil {
+		panic(err)
+	}
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write(body)
+	}
+}
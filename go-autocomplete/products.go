@@ -0,0 +1,149 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/elastic/go-elasticsearch/v8/esapi"
+)
+
+// productSuggestion은 키워드 대신 상품 상세로 바로 이동하는 추천 항목입니다.
+type productSuggestion struct {
+	ID    string   `json:"id"`
+	Name  string   `json:"name"`
+	Price *float64 `json:"price,omitempty"`
+	Image string   `json:"image,omitempty"`
+}
+
+// includeParam은 include 쿼리를 확인합니다. 현재는 products만 받습니다.
+func includeParam(w http.ResponseWriter, r *http.Request) (products, ok bool) {
+	raw := strings.TrimSpace(r.URL.Query().Get("include"))
+	if raw == "" {
+		return false, true
+	}
+	for _, v := range strings.Split(raw, ",") {
+		if strings.TrimSpace(v) != "products" {
+			invalidParameter(w, r, "include")
+			return false, false
+		}
+	}
+	return true, true
+}
+
+// suggestProducts는 접두어에 맞는 상품을 찾습니다. PRODUCT_INDEX가 있으면 상품 인덱스에서,
+// 없으면 meta.product_id가 있는 키워드 문서에서 가중치순으로 가져옵니다.
+// 채널은 completion context에만 있어 meta 방식에서는 채널로 거르지 않습니다.
+func (s *store) suggestProducts(ctx context.Context, c config, q string) ([]productSuggestion, error) {
+	if c.ProductIndex != "" {
+		return s.searchProductIndex(ctx, c.ProductIndex, c.ProductNameField, q, c.ProductSuggestSize)
+	}
+
+	query := map[string]interface{}{
+		"size":    c.ProductSuggestSize,
+		"_source": []string{"display", "keyword", "meta.product_id", "meta.name", "meta.price", "meta.image"},
+		"query": map[string]interface{}{"bool": map[string]interface{}{
+			"filter": []interface{}{
+				map[string]interface{}{"prefix": map[string]interface{}{"keyword": matchKey(q)}},
+				map[string]interface{}{"exists": map[string]interface{}{"field": "meta.product_id"}},
+				notExpiredQuery(),
+			},
+		}},
+		"sort": []interface{}{map[string]interface{}{"weight": "desc"}},
+	}
+	hits, err := s.searchSources(ctx, s.index, query)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]productSuggestion, 0, len(hits))
+	for _, hit := range hits {
+		meta, _ := hit.Source["meta"].(map[string]interface{})
+		p, ok := productFromSource(meta, "product_id", "name")
+		if !ok {
+			continue
+		}
+		if p.Name == "" {
+			p.Name, _ = hit.Source["display"].(string)
+		}
+		if p.Name == "" {
+			p.Name, _ = hit.Source["keyword"].(string)
+		}
+		out = append(out, p)
+	}
+	return out, nil
+}
+
+func (s *store) searchProductIndex(ctx context.Context, index, nameField, q string, size int) ([]productSuggestion, error) {
+	query := map[string]interface{}{
+		"size":    size,
+		"_source": []string{"id", nameField, "price", "image"},
+		"query": map[string]interface{}{
+			"match_bool_prefix": map[string]interface{}{nameField: map[string]interface{}{"query": q, "operator": "and"}},
+		},
+	}
+	hits, err := s.searchSources(ctx, index, query)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]productSuggestion, 0, len(hits))
+	for _, hit := range hits {
+		if _, ok := hit.Source["id"]; !ok {
+			hit.Source["id"] = hit.ID
+		}
+		if p, ok := productFromSource(hit.Source, "id", nameField); ok {
+			out = append(out, p)
+		}
+	}
+	return out, nil
+}
+
+func productFromSource(src map[string]interface{}, idField, nameField string) (productSuggestion, bool) {
+	var p productSuggestion
+	switch id := src[idField].(type) {
+	case string:
+		p.ID = id
+	case float64:
+		p.ID = fmt.Sprintf("%.0f", id)
+	}
+	if p.ID == "" {
+		return p, false
+	}
+	p.Name, _ = src[nameField].(string)
+	if price, ok := src["price"].(float64); ok {
+		p.Price = &price
+	}
+	p.Image, _ = src["image"].(string)
+	return p, true
+}
+
+type sourceHit struct {
+	ID     string                 `json:"_id"`
+	Source map[string]interface{} `json:"_source"`
+}
+
+func (s *store) searchSources(ctx context.Context, index string, query map[string]interface{}) ([]sourceHit, error) {
+	body, err := json.Marshal(query)
+	if err != nil {
+		return nil, fmt.Errorf("쿼리 직렬화 실패: %w", err)
+	}
+	res, err := esapi.SearchRequest{Index: []string{index}, Body: bytes.NewReader(body)}.Do(ctx, s.client)
+	if err != nil {
+		return nil, fmt.Errorf("검색 요청 실패: %w", err)
+	}
+	defer discard(res.Body)
+	if res.IsError() {
+		return nil, fmt.Errorf("검색 응답 에러: %s", res.String())
+	}
+	var parsed struct {
+		Hits struct {
+			Hits []sourceHit `json:"hits"`
+		} `json:"hits"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&parsed); err != nil {
+		return nil, fmt.Errorf("응답 파싱 실패: %w", err)
+	}
+	return parsed.Hits.Hits, nil
+}
@@ -0,0 +1,135 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"expvar"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/elastic/go-elasticsearch/v8/esapi"
+)
+
+const queryLogAlias = "autocomplete-queries"
+
+// queryLogMapping은 suggest 요청 로그 인덱스의 매핑입니다. results는 ES를 조회한 요청에만 있고
+// ETag로 304를 돌려준 요청에는 not_modified만 남습니다.
+const queryLogMapping = `{
+  "properties": {
+    "@timestamp":   { "type": "date" },
+    "prefix":       { "type": "keyword" },
+    "locale":       { "type": "keyword" },
+    "channel":      { "type": "keyword" },
+    "results":      { "type": "integer" },
+    "zero_result":  { "type": "boolean" },
+    "not_modified": { "type": "boolean" }
+  }
+}`
+
+var queryLogDropped = expvar.NewInt("query_log_dropped_total")
+
+type queryLogEntry struct {
+	Timestamp   time.Time `json:"@timestamp"`
+	Prefix      string    `json:"prefix"`
+	Locale      string    `json:"locale"`
+	Channel     string    `json:"channel,omitempty"`
+	Results     *int      `json:"results,omitempty"`
+	ZeroResult  bool      `json:"zero_result"`
+	NotModified bool      `json:"not_modified,omitempty"`
+}
+
+// queryLogger는 suggest 요청을 모아 쿼리 로그 인덱스에 bulk로 씁니다. 로그 때문에 suggest가 느려지면
+// 안 되므로 큐가 차면 버립니다.
+type queryLogger struct {
+	st         *store
+	queue      chan queryLogEntry
+	batchSize  int
+	flushEvery time.Duration
+}
+
+func newQueryLogger(st *store, queueSize, batchSize int, flushEvery time.Duration) *queryLogger {
+	return &queryLogger{
+		st:         st,
+		queue:      make(chan queryLogEntry, queueSize),
+		batchSize:  batchSize,
+		flushEvery: flushEvery,
+	}
+}
+
+func (ql *queryLogger) record(q, locale, channel string, results int, notModified bool) {
+	if ql == nil {
+		return
+	}
+	entry := queryLogEntry{
+		Timestamp:   time.Now().UTC(),
+		Prefix:      matchKey(q),
+		Locale:      locale,
+		Channel:     channel,
+		NotModified: notModified,
+	}
+	if !notModified {
+		entry.Results = &results
+		entry.ZeroResult = results == 0
+	}
+	select {
+	case ql.queue <- entry:
+	default:
+		queryLogDropped.Add(1)
+	}
+}
+
+func (ql *queryLogger) run(ctx context.Context) {
+	ticker := time.NewTicker(ql.flushEvery)
+	defer ticker.Stop()
+	batch := make([]queryLogEntry, 0, ql.batchSize)
+	flush := func(ctx context.Context) {
+		if len(batch) == 0 {
+			return
+		}
+		ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+		defer cancel()
+		if err := ql.st.appendQueryLog(ctx, batch); err != nil {
+			queryLogDropped.Add(int64(len(batch)))
+			log.Printf("쿼리 로그 적재 실패 (%d건 버림): %v", len(batch), err)
+		}
+		batch = batch[:0]
+	}
+	for {
+		select {
+		case <-ctx.Done():
+			flush(context.Background())
+			return
+		case entry := <-ql.queue:
+			batch = append(batch, entry)
+			if len(batch) >= ql.batchSize {
+				flush(ctx)
+			}
+		case <-ticker.C:
+			flush(ctx)
+		}
+	}
+}
+
+func (s *store) appendQueryLog(ctx context.Context, entries []queryLogEntry) error {
+	var buf bytes.Buffer
+	for _, entry := range entries {
+		b, err := json.Marshal(entry)
+		if err != nil {
+			return fmt.Errorf("쿼리 로그 직렬화 실패: %w", err)
+		}
+		buf.WriteString(`{"create":{}}` + "\n")
+		buf.Write(b)
+		buf.WriteByte('\n')
+	}
+	res, err := esapi.BulkRequest{Index: queryLogAlias, Body: &buf}.Do(ctx, s.client)
+	if err != nil {
+		return fmt.Errorf("bulk 요청 실패: %w", err)
+	}
+	defer discard(res.Body)
+	if res.IsError() {
+		return fmt.Errorf("bulk 응답 에러: %s", res.String())
+	}
+	return nil
+}
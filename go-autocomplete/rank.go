@@ -0,0 +1,108 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+)
+
+// clickScript는 클릭 수를 반감기로 감쇠하는 누적 점수(click_score)로 기록합니다. 마지막 갱신 시각(click_score_at,
+// epoch ms)에서 지금까지 감쇠한 뒤 1을 더하므로 최근 클릭일수록 크게 반영됩니다.
+const clickScript = `double score = 0;
+if (ctx._source.click_score instanceof Number && ctx._source.click_score_at instanceof Number) {
+  double age = params.now_ms - ((Number) ctx._source.click_score_at).longValue();
+  score = ((Number) ctx._source.click_score).doubleValue() * Math.pow(0.5, Math.max(age, 0) / params.half_life_ms);
+}
+ctx._source.click_score = score + 1;
+ctx._source.click_score_at = params.now_ms;
+ctx._source.last_clicked_at = params.now;`
+
+func clickBody(now time.Time, halfLife time.Duration) map[string]interface{} {
+	return map[string]interface{}{
+		"script": map[string]interface{}{
+			"lang":   "painless",
+			"source": clickScript,
+			"params": map[string]interface{}{
+				"now":          now.UTC().Format(time.RFC3339),
+				"now_ms":       now.UnixMilli(),
+				"half_life_ms": halfLife.Milliseconds(),
+			},
+		},
+	}
+}
+
+// ranking은 suggester 후보를 저장 가중치, 최근성(last_seen_at), 클릭 인기도의 가중합으로 다시 정렬합니다.
+//
+//	score = Weight×ln(1+weight) + Recency×0.5^(경과/RecencyHalfLife) + Clicks×ln(1+감쇠한 click_score)
+//
+// 가중치와 클릭 수는 범위가 넓어 로그로 줄입니다. Recency와 Clicks가 0이면 suggester 순서를 그대로 씁니다.
+type ranking struct {
+	Weight          float64
+	Recency         float64
+	Clicks          float64
+	RecencyHalfLife time.Duration
+	ClickHalfLife   time.Duration
+}
+
+func (t tunables) ranking() ranking {
+	return ranking{
+		Weight:          t.RankWeight,
+		Recency:         t.RankRecency,
+		Clicks:          t.RankClicks,
+		RecencyHalfLife: t.RankRecencyHalfLife,
+		ClickHalfLife:   t.RankClickHalfLife,
+	}
+}
+
+func (rk ranking) key() string {
+	return fmt.Sprintf("%g,%g,%g,%s,%s", rk.Weight, rk.Recency, rk.Clicks, rk.RecencyHalfLife, rk.ClickHalfLife)
+}
+
+func (rk ranking) enabled() bool {
+	return rk.Recency != 0 || rk.Clicks != 0
+}
+
+func (rk ranking) score(c scoredSuggestion, now time.Time) float64 {
+	score := rk.Weight * math.Log1p(math.Max(c.Weight, 0))
+	if rk.Recency != 0 && c.LastSeenAt != nil {
+		score += rk.Recency * decay(now.Sub(*c.LastSeenAt), rk.RecencyHalfLife)
+	}
+	if rk.Clicks != 0 && c.ClickScore > 0 {
+		score += rk.Clicks * math.Log1p(c.ClickScore*decay(now.Sub(c.ClickScoreAt), rk.ClickHalfLife))
+	}
+	return score
+}
+
+// rerank는 후보를 다시 정렬해 앞에서 limit개를 돌려줍니다. 점수가 같으면 suggester 순서를 유지합니다.
+// 순위 조정이 꺼져 있으면 suggester 순서(가중치)를 그대로 쓰고 가중치를 Score로 둡니다.
+// 입력은 캐시와 공유하므로 고치지 않고 새 슬라이스에 씁니다.
+func (rk ranking) rerank(in []scoredSuggestion, limit int, now time.Time) []scoredSuggestion {
+	out := make([]scoredSuggestion, len(in))
+	if !rk.enabled() {
+		for i, c := range in {
+			out[i] = c
+			out[i].Score = c.Weight
+		}
+		return topSuggestions(out, limit)
+	}
+	order := make([]int, len(in))
+	scores := make([]float64, len(in))
+	for i, c := range in {
+		order[i], scores[i] = i, rk.score(c, now)
+	}
+	sort.SliceStable(order, func(a, b int) bool { return scores[order[a]] > scores[order[b]] })
+	for i, j := range order {
+		out[i] = in[j]
+		out[i].Score = scores[j]
+	}
+	return topSuggestions(out, limit)
+}
+
+// decay는 경과 시간 age에 대한 반감기 감쇠 계수(0~1)입니다.
+func decay(age, halfLife time.Duration) float64 {
+	if halfLife <= 0 || age <= 0 {
+		return 1
+	}
+	return math.Pow(0.5, float64(age)/float64(halfLife))
+}
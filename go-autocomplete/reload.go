@@ -0,0 +1,279 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"reflect"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// tunables는 재시작 없이 다시 읽어 적용할 수 있는 설정입니다. 나머지 설정은 클라이언트, 큐, 백그라운드
+// 루프를 만들 때 한 번 쓰이므로 바뀌어도 재시작해야 반영됩니다.
+type tunables struct {
+	SuggestTimeout      time.Duration
+	UpsertTimeout       time.Duration
+	MinPrefixLength     int
+	MinPrefixLengthCJK  int
+	FuzzyRetry          bool
+	Fuzziness           string
+	RankWeight          float64
+	RankRecency         float64
+	RankClicks          float64
+	RankRecencyHalfLife time.Duration
+	RankClickHalfLife   time.Duration
+	InputSanitize       string
+	MaxConcurrentReads  int
+	MaxConcurrentWrites int
+	ConcurrencyWait     time.Duration
+	CacheSize           int
+	CacheTTL            time.Duration
+}
+
+func (c config) tunables() tunables {
+	return tunables{
+		SuggestTimeout:      c.SuggestTimeout,
+		UpsertTimeout:       c.UpsertTimeout,
+		MinPrefixLength:     c.MinPrefixLength,
+		MinPrefixLengthCJK:  c.MinPrefixLengthCJK,
+		FuzzyRetry:          c.FuzzyRetry,
+		Fuzziness:           c.Fuzziness,
+		RankWeight:          c.RankWeight,
+		RankRecency:         c.RankRecency,
+		RankClicks:          c.RankClicks,
+		RankRecencyHalfLife: c.RankRecencyHalfLife,
+		RankClickHalfLife:   c.RankClickHalfLife,
+		InputSanitize:       c.InputSanitize,
+		MaxConcurrentReads:  c.MaxConcurrentReads,
+		MaxConcurrentWrites: c.MaxConcurrentWrites,
+		ConcurrencyWait:     c.ConcurrencyWait,
+		CacheSize:           c.CacheSize,
+		CacheTTL:            c.CacheTTL,
+	}
+}
+
+func (t tunables) validate() error {
+	if t.SuggestTimeout <= 0 || t.UpsertTimeout <= 0 {
+		return fmt.Errorf("SUGGEST_TIMEOUT/UPSERT_TIMEOUT는 0보다 커야 합니다")
+	}
+	if t.RankClickHalfLife <= 0 {
+		return fmt.Errorf("RANK_CLICK_HALF_LIFE는 0보다 커야 합니다")
+	}
+	if t.CacheSize < 0 || t.CacheTTL < 0 {
+		return fmt.Errorf("SUGGEST_CACHE_SIZE/SUGGEST_CACHE_TTL는 0 이상이어야 합니다")
+	}
+	if t.InputSanitize != sanitizeStrip && t.InputSanitize != sanitizeReject {
+		return fmt.Errorf("알 수 없는 INPUT_SANITIZE: %q", t.InputSanitize)
+	}
+	return nil
+}
+
+// live는 현재 적용 중인 tunables를 돌려줍니다. 아직 reload가 없었다면 시작 시 설정을 씁니다.
+func (s *server) live() tunables {
+	if t := s.tune.Load(); t != nil {
+		return *t
+	}
+	return s.cfg.tunables()
+}
+
+// configFile은 CONFIG_FILE의 KEY=VALUE 줄을 환경 변수로 올립니다. 파일에서 빠진 키는 원래 환경 변수 값으로 되돌려
+// 파일을 고친 결과와 실제 설정이 어긋나지 않게 합니다.
+type configFile struct {
+	path string
+
+	mu   sync.Mutex
+	orig map[string]*string
+}
+
+func newConfigFile(path string) *configFile {
+	if path == "" {
+		return nil
+	}
+	return &configFile{path: path, orig: map[string]*string{}}
+}
+
+func (f *configFile) apply() error {
+	if f == nil {
+		return nil
+	}
+	values, err := readConfigFile(f.path)
+	if err != nil {
+		return err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for key, orig := range f.orig {
+		if _, ok := values[key]; ok {
+			continue
+		}
+		if orig == nil {
+			os.Unsetenv(key)
+		} else {
+			os.Setenv(key, *orig)
+		}
+		delete(f.orig, key)
+	}
+	for key, value := range values {
+		if _, ok := f.orig[key]; !ok {
+			var orig *string
+			if v, ok := os.LookupEnv(key); ok {
+				orig = &v
+			}
+			f.orig[key] = orig
+		}
+		os.Setenv(key, value)
+	}
+	return nil
+}
+
+func readConfigFile(path string) (map[string]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("설정 파일 열기 실패: %w", err)
+	}
+	defer file.Close()
+
+	values := map[string]string{}
+	scanner := bufio.NewScanner(file)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		key, value, ok := strings.Cut(strings.TrimPrefix(line, "export "), "=")
+		key = strings.TrimSpace(key)
+		if !ok || key == "" {
+			return nil, fmt.Errorf("설정 파일 %d번째 줄 형식 오류: %q", n, line)
+		}
+		value = strings.TrimSpace(value)
+		if len(value) >= 2 && (value[0] == '"' || value[0] == '\'') && value[len(value)-1] == value[0] {
+			value = value[1 : len(value)-1]
+		}
+		values[key] = value
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("설정 파일 읽기 실패: %w", err)
+	}
+	return values, nil
+}
+
+type reloadResult struct {
+	Changed         []string `json:"changed"`
+	RestartRequired []string `json:"restart_required"`
+}
+
+// reload는 설정을 다시 읽어 tunables를 바꿔 끼웁니다. 진행 중인 요청과 연결은 그대로 두고
+// 이후 요청부터 새 값을 씁니다. 검증에 실패하면 기존 설정을 유지합니다.
+func (s *server) reload() (reloadResult, error) {
+	s.reloadMu.Lock()
+	defer s.reloadMu.Unlock()
+
+	if err := s.cfgFile.apply(); err != nil {
+		return reloadResult{}, err
+	}
+	next := loadConfig()
+	t := next.tunables()
+	if err := t.validate(); err != nil {
+		return reloadResult{}, err
+	}
+
+	prev := s.live()
+	res := reloadResult{Changed: []string{}, RestartRequired: []string{}}
+	tv, pv := reflect.ValueOf(t), reflect.ValueOf(prev)
+	reloadable := map[string]bool{}
+	for i := 0; i < tv.NumField(); i++ {
+		name := tv.Type().Field(i).Name
+		reloadable[name] = true
+		if !reflect.DeepEqual(tv.Field(i).Interface(), pv.Field(i).Interface()) {
+			res.Changed = append(res.Changed, name)
+		}
+	}
+	cv, ov := reflect.ValueOf(next), reflect.ValueOf(s.cfg)
+	for i := 0; i < cv.NumField(); i++ {
+		name := cv.Type().Field(i).Name
+		if !reloadable[name] && !reflect.DeepEqual(cv.Field(i).Interface(), ov.Field(i).Interface()) {
+			res.RestartRequired = append(res.RestartRequired, name)
+		}
+	}
+
+	s.tune.Store(&t)
+	if t.MaxConcurrentReads > 0 && t.MaxConcurrentWrites > 0 {
+		for _, lt := range s.limiters() {
+			lt.resize(t.MaxConcurrentReads, t.MaxConcurrentWrites, t.ConcurrencyWait)
+		}
+	}
+	s.cache.resize(t.CacheSize)
+	return res, nil
+}
+
+// limiters는 이 서버가 쓰는 모든 클러스터의 동시 요청 제한기를 모읍니다.
+func (s *server) limiters() []*limitedTransport {
+	var stores []*store
+	if s.reads != nil {
+		for _, ep := range s.reads.endpoints {
+			stores = append(stores, ep.st)
+		}
+	}
+	stores = append(stores, s.st)
+	if s.mir != nil {
+		stores = append(stores, s.mir.target)
+	}
+
+	seen := map[*limitedTransport]bool{}
+	var out []*limitedTransport
+	for _, st := range stores {
+		if lt, ok := st.client.(*limitedTransport); ok && !seen[lt] {
+			seen[lt] = true
+			out = append(out, lt)
+		}
+	}
+	return out
+}
+
+func (s *server) logReload(ctx context.Context, res reloadResult, err error) {
+	if err != nil {
+		logf(ctx, "설정 다시 읽기 실패, 기존 설정 유지: %v", err)
+		return
+	}
+	logf(ctx, "설정 다시 읽기 완료: 변경 %v", res.Changed)
+	if len(res.RestartRequired) > 0 {
+		logf(ctx, "재시작해야 반영되는 설정 변경: %v", res.RestartRequired)
+	}
+}
+
+// reloadOnSignal은 SIGHUP을 받을 때마다 설정을 다시 읽습니다.
+func (s *server) reloadOnSignal(ctx context.Context) {
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	defer signal.Stop(hup)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-hup:
+			res, err := s.reload()
+			s.logReload(ctx, res, err)
+		}
+	}
+}
+
+func (s *server) handleReload(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		methodNotAllowed(w, r, http.MethodPost)
+		return
+	}
+	res, err := s.reload()
+	s.logReload(r.Context(), res, err)
+	if err != nil {
+		writeErrorDetails(w, r, codeReloadFailed, map[string]string{"error": err.Error()})
+		return
+	}
+	writeJSON(w, res)
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/elastic/go-elasticsearch/v8/esapi"
+)
+
+const requestIDHeader = "X-Request-ID"
+
+type ctxKey int
+
+const requestIDKey ctxKey = iota
+
+// withRequestID는 게이트웨이가 넘긴 X-Request-ID를 그대로 쓰거나 새로 만들어
+// 응답 헤더와 요청 context에 심습니다.
+func withRequestID(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(requestIDHeader)
+		if !validRequestID(id) {
+			id = newRequestID()
+		}
+		w.Header().Set(requestIDHeader, id)
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), requestIDKey, id)))
+	})
+}
+
+func validRequestID(id string) bool {
+	if id == "" || len(id) > 128 {
+		return false
+	}
+	for _, c := range id {
+		if c < 0x21 || c > 0x7e {
+			return false
+		}
+	}
+	return true
+}
+
+func newRequestID() string {
+	buf := make([]byte, 16)
+	_, _ = rand.Read(buf)
+	return hex.EncodeToString(buf)
+}
+
+func requestIDFrom(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey).(string)
+	return id
+}
+
+func logf(ctx context.Context, format string, args ...interface{}) {
+	if id := requestIDFrom(ctx); id != "" {
+		format = "[req=" + id + "] " + format
+	}
+	log.Output(2, fmt.Sprintf(format, args...))
+}
+
+// opaqueIDTransport는 요청 ID를 X-Opaque-Id로 ES에 전달해 슬로우 로그와 API 요청을 연결합니다.
+type opaqueIDTransport struct {
+	next esapi.Transport
+}
+
+func (t opaqueIDTransport) Perform(req *http.Request) (*http.Response, error) {
+	if id := requestIDFrom(req.Context()); id != "" && req.Header.Get("X-Opaque-Id") == "" {
+		req.Header.Set("X-Opaque-Id", id)
+	}
+	return t.next.Perform(req)
+}
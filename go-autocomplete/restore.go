@@ -0,0 +1,320 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/elastic/go-elasticsearch/v8/esapi"
+)
+
+const restoreBatchSize = 1000
+
+// restore는 스냅샷을 로케일마다 새 버전 인덱스(<로케일 인덱스>-v<시각>)에 적재한 뒤 로케일 별칭을
+// 한 번의 요청으로 원자적으로 교체합니다. 스냅샷에 문서가 없는 추가 로케일은 건드리지 않고, 설정에 없는 로케일의
+// 문서는 건너뜁니다. 진행 상황은 jobs에 기록합니다.
+func (sn *snapshotter) restore(ctx context.Context, key string, jobs *jobRegistry, jobID string) (map[string]interface{}, error) {
+	if !strings.HasPrefix(key, sn.prefix) {
+		key = sn.prefix + key
+	}
+	obj, err := sn.s3.GetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(sn.bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("스냅샷 다운로드 실패 (%s): %w", key, err)
+	}
+	defer obj.Body.Close()
+
+	counter := &countingReader{r: obj.Body}
+	gz, err := gzip.NewReader(counter)
+	if err != nil {
+		return nil, fmt.Errorf("gzip 열기 실패: %w", err)
+	}
+	defer gz.Close()
+
+	known := make(map[string]bool, len(sn.locales))
+	for _, locale := range sn.locales {
+		known[locale] = true
+	}
+	suffix := "-v" + time.Now().UTC().Format(snapshotKeyLayout)
+	targets := map[string]string{}
+	prepare := func(locale string) (string, error) {
+		if target, ok := targets[locale]; ok {
+			return target, nil
+		}
+		st := sn.st.withLocale(locale)
+		target := strings.ToLower(st.index + suffix)
+		mapping, err := st.settings.render()
+		if err != nil {
+			return "", err
+		}
+		if err := st.createIndex(ctx, target, mapping); err != nil {
+			return "", err
+		}
+		if err := st.applyTuning(ctx, target, bulkLoadTuning); err != nil {
+			return "", err
+		}
+		targets[locale] = target
+		return target, nil
+	}
+	// 이전 형식의 스냅샷은 기본 로케일뿐이고, 비어 있는 스냅샷도 기본 인덱스를 비운 상태로 교체합니다.
+	if _, err := prepare(""); err != nil {
+		return nil, err
+	}
+
+	total := aws.ToInt64(obj.ContentLength)
+	processed := 0
+	skipped := map[string]int{}
+	batches := map[string][]storedDoc{}
+	flush := func(locale string) error {
+		batch := batches[locale]
+		if len(batch) == 0 {
+			return nil
+		}
+		if err := sn.st.bulkIndex(ctx, targets[locale], batch); err != nil {
+			return err
+		}
+		processed += len(batch)
+		batches[locale] = batch[:0]
+		fraction := 0.0
+		if total > 0 {
+			fraction = float64(counter.n.Load()) / float64(total)
+		}
+		jobs.progress(jobID, processed, fraction)
+		return nil
+	}
+
+	scanner := bufio.NewScanner(gz)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		var doc storedDoc
+		if err := json.Unmarshal(line, &doc); err != nil {
+			return nil, fmt.Errorf("스냅샷 라인 파싱 실패: %w", err)
+		}
+		if !known[doc.Locale] {
+			skipped[doc.Locale]++
+			continue
+		}
+		if _, err := prepare(doc.Locale); err != nil {
+			return nil, err
+		}
+		batches[doc.Locale] = append(batches[doc.Locale], doc)
+		if len(batches[doc.Locale]) == restoreBatchSize {
+			if err := flush(doc.Locale); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("스냅샷 읽기 실패: %w", err)
+	}
+
+	swaps := make(map[string]string, len(targets))
+	for locale, target := range targets {
+		st := sn.st.withLocale(locale)
+		if err := flush(locale); err != nil {
+			return nil, err
+		}
+		if err := st.applyTuning(ctx, target, indexTuning{Replicas: st.settings.Replicas}); err != nil {
+			return nil, err
+		}
+		if err := st.refreshIndex(ctx, target); err != nil {
+			return nil, err
+		}
+		swaps[st.index] = target
+	}
+
+	previous, err := sn.st.swapAliases(ctx, swaps)
+	if err != nil {
+		return nil, err
+	}
+	for locale, n := range skipped {
+		log.Printf("스냅샷 복원: 설정에 없는 로케일 %q 문서 %d건 건너뜀", locale, n)
+	}
+	log.Printf("스냅샷 복원 완료: %s → %v (%d건)", key, swaps, processed)
+	// 별칭에서 빠진 이전 인덱스는 다시 쓰이지 않으므로 지웁니다. 별칭 자리에 있던 실제 인덱스는 교체 요청의
+	// remove_index로 이미 지워졌습니다.
+	var deleted, kept []string
+	for _, index := range previous {
+		if _, isAlias := swaps[index]; isAlias {
+			deleted = append(deleted, index)
+			continue
+		}
+		if err := sn.st.deleteIndex(ctx, index); err != nil {
+			log.Printf("스냅샷 복원: 이전 인덱스 삭제 실패 (%s): %v", index, err)
+			kept = append(kept, index)
+			continue
+		}
+		deleted = append(deleted, index)
+	}
+	result := map[string]interface{}{
+		"snapshot":        key,
+		"index":           targets[""],
+		"indices":         swaps,
+		"documents":       processed,
+		"deleted_indices": deleted,
+	}
+	if len(kept) > 0 {
+		result["previous_indices"] = kept
+	}
+	if len(skipped) > 0 {
+		result["skipped"] = skipped
+	}
+	return result, nil
+}
+
+type countingReader struct {
+	r io.Reader
+	n atomic.Int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n.Add(int64(n))
+	return n, err
+}
+
+func (s *store) bulkIndex(ctx context.Context, index string, docs []storedDoc) error {
+	var buf bytes.Buffer
+	for _, doc := range docs {
+		action := map[string]interface{}{"index": map[string]interface{}{"_id": doc.ID}}
+		line, err := json.Marshal(action)
+		if err != nil {
+			return fmt.Errorf("bulk 액션 직렬화 실패: %w", err)
+		}
+		buf.Write(line)
+		buf.WriteByte('\n')
+		buf.Write(doc.Source)
+		buf.WriteByte('\n')
+	}
+
+	res, err := esapi.BulkRequest{Index: index, Body: &buf}.Do(ctx, s.client)
+	if err != nil {
+		return fmt.Errorf("bulk 요청 실패: %w", err)
+	}
+	defer discard(res.Body)
+	if res.IsError() {
+		return fmt.Errorf("bulk 응답 에러: %s", res.String())
+	}
+	var parsed struct {
+		Errors bool `json:"errors"`
+		Items  []map[string]struct {
+			ID    string          `json:"_id"`
+			Error json.RawMessage `json:"error"`
+		} `json:"items"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&parsed); err != nil {
+		return fmt.Errorf("bulk 응답 파싱 실패: %w", err)
+	}
+	if parsed.Errors {
+		for _, item := range parsed.Items {
+			for _, result := range item {
+				if len(result.Error) > 0 {
+					return fmt.Errorf("bulk 항목 실패 (%s): %s", result.ID, result.Error)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func (s *store) deleteIndex(ctx context.Context, index string) error {
+	res, err := esapi.IndicesDeleteRequest{Index: []string{index}}.Do(ctx, s.client)
+	if err != nil {
+		return fmt.Errorf("인덱스 삭제 실패: %w", err)
+	}
+	defer discard(res.Body)
+	if res.IsError() && res.StatusCode != http.StatusNotFound {
+		return fmt.Errorf("인덱스 삭제 응답 에러: %s", res.String())
+	}
+	return nil
+}
+
+// swapAliases는 별칭마다 target 인덱스로 옮기는 작업을 한 번의 요청으로 보내고, 이전에 별칭이 가리키던 인덱스
+// 목록을 돌려줍니다. 별칭 이름이 별칭이 아닌 실제 인덱스(초기 배포)라면 같은 요청에서 삭제하고 별칭으로 대체합니다.
+func (s *store) swapAliases(ctx context.Context, targets map[string]string) ([]string, error) {
+	var previous []string
+	actions := []interface{}{}
+	for alias, target := range targets {
+		prev, acts, err := s.aliasActions(ctx, alias, target)
+		if err != nil {
+			return nil, err
+		}
+		previous = append(previous, prev...)
+		actions = append(actions, acts...)
+	}
+
+	body, err := json.Marshal(map[string]interface{}{"actions": actions})
+	if err != nil {
+		return nil, fmt.Errorf("별칭 요청 직렬화 실패: %w", err)
+	}
+	updateRes, err := esapi.IndicesUpdateAliasesRequest{Body: bytes.NewReader(body)}.Do(ctx, s.client)
+	if err != nil {
+		return nil, fmt.Errorf("별칭 교체 실패: %w", err)
+	}
+	defer discard(updateRes.Body)
+	if updateRes.IsError() {
+		return nil, fmt.Errorf("별칭 교체 응답 에러: %s", updateRes.String())
+	}
+	return previous, nil
+}
+
+func (s *store) aliasActions(ctx context.Context, alias, target string) ([]string, []interface{}, error) {
+	res, err := esapi.IndicesGetAliasRequest{Name: []string{alias}}.Do(ctx, s.client)
+	if err != nil {
+		return nil, nil, fmt.Errorf("별칭 조회 실패: %w", err)
+	}
+	var previous []string
+	actions := []interface{}{}
+	switch {
+	case res.StatusCode == http.StatusOK:
+		var aliases map[string]json.RawMessage
+		err := json.NewDecoder(res.Body).Decode(&aliases)
+		discard(res.Body)
+		if err != nil {
+			return nil, nil, fmt.Errorf("별칭 응답 파싱 실패: %w", err)
+		}
+		for index := range aliases {
+			previous = append(previous, index)
+			actions = append(actions, map[string]interface{}{
+				"remove": map[string]interface{}{"index": index, "alias": alias},
+			})
+		}
+	case res.StatusCode == http.StatusNotFound:
+		discard(res.Body)
+		exists, err := esapi.IndicesExistsRequest{Index: []string{alias}}.Do(ctx, s.client)
+		if err != nil {
+			return nil, nil, fmt.Errorf("인덱스 확인 실패: %w", err)
+		}
+		discard(exists.Body)
+		if exists.StatusCode == http.StatusOK {
+			previous = append(previous, alias)
+			actions = append(actions, map[string]interface{}{
+				"remove_index": map[string]interface{}{"index": alias},
+			})
+		}
+	default:
+		discard(res.Body)
+		return nil, nil, fmt.Errorf("별칭 조회 응답 코드: %d", res.StatusCode)
+	}
+	actions = append(actions, map[string]interface{}{
+		"add": map[string]interface{}{"index": target, "alias": alias},
+	})
+	return previous, actions, nil
+}
@@ -0,0 +1,74 @@
+package main
+
+import "strings"
+
+// 국어의 로마자 표기법(Revised Romanization) 자모표입니다. 음운 변화는 연음과 ㄹㄹ만 반영합니다.
+var (
+	romanInitials = [...]string{"g", "kk", "n", "d", "tt", "r", "m", "b", "pp", "s", "ss", "", "j", "jj", "ch", "k", "t", "p", "h"}
+	romanMedials  = [...]string{"a", "ae", "ya", "yae", "eo", "e", "yeo", "ye", "o", "wa", "wae", "oe", "yo", "u", "wo", "we", "wi", "yu", "eu", "ui", "i"}
+	romanFinals   = [...]string{"", "k", "k", "k", "n", "n", "n", "t", "l", "k", "m", "l", "l", "l", "p", "l", "m", "p", "p", "t", "t", "ng", "t", "t", "k", "t", "p", "t"}
+	// romanLiaison은 뒤 음절이 모음(초성 ㅇ)으로 시작할 때 넘어가는 받침 소리입니다. 빈 값이면 연음하지 않습니다.
+	romanLiaison = [...]string{"", "g", "kk", "", "n", "", "", "d", "r", "", "", "", "", "", "", "", "m", "b", "", "s", "ss", "", "j", "ch", "k", "t", "p", ""}
+)
+
+const (
+	hangulBase    = 0xAC00
+	hangulLast    = 0xD7A3
+	initialSilent = 11 // ㅇ
+	initialRieul  = 5  // ㄹ
+	finalRieul    = 8  // ㄹ
+)
+
+// romanize는 한글 음절을 로마자로 바꿉니다("신사" → "sinsa"). 한글이 아닌 문자는 그대로 둡니다.
+func romanize(s string) string {
+	runes := []rune(s)
+	var b strings.Builder
+	carried := ""
+	for i, r := range runes {
+		if r < hangulBase || r > hangulLast {
+			b.WriteString(carried)
+			carried = ""
+			b.WriteRune(r)
+			continue
+		}
+		idx := int(r - hangulBase)
+		initial, medial, final := idx/(21*28), (idx%(21*28))/28, idx%28
+
+		if carried != "" {
+			b.WriteString(carried)
+			carried = ""
+		} else {
+			b.WriteString(romanInitials[initial])
+		}
+		b.WriteString(romanMedials[medial])
+
+		next := -1
+		if i+1 < len(runes) && runes[i+1] >= hangulBase && runes[i+1] <= hangulLast {
+			next = int(runes[i+1]-hangulBase) / (21 * 28)
+		}
+		switch {
+		case final == 0:
+		case next == initialSilent && romanLiaison[final] != "":
+			carried = romanLiaison[final]
+		case final == finalRieul && next == initialRieul:
+			b.WriteString("l")
+			carried = "l"
+		default:
+			b.WriteString(romanFinals[final])
+		}
+	}
+	b.WriteString(carried)
+	return b.String()
+}
+
+// suggestInputs는 completion 입력 목록입니다. 로마자 변형을 켜면 한글 키워드에 라틴 입력을 더해
+// "sinsa"로 입력해도 "신사"가 추천되게 합니다.
+func suggestInputs(keyword string, withRoman bool) []string {
+	inputs := []string{keyword}
+	if withRoman {
+		if roman := romanize(keyword); roman != keyword {
+			inputs = append(inputs, roman)
+		}
+	}
+	return inputs
+}
@@ -0,0 +1,244 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/elastic/go-elasticsearch/v8/esapi"
+)
+
+const (
+	salesFormulaLinear = "linear"
+	salesFormulaLog    = "log"
+)
+
+// salesCount는 order-service가 집계해 돌려주는 키워드별 판매 건수입니다.
+//
+//	GET <SALES_SOURCE_URL>?from=<RFC3339>&to=<RFC3339>
+//	{ "items": [ { "keyword": "iphone 15", "count": 1234 } ] }
+type salesCount struct {
+	Keyword string `json:"keyword"`
+	Count   int64  `json:"count"`
+}
+
+type salesWeights struct {
+	Formula string
+	Base    float64
+	Factor  float64
+	Max     int
+}
+
+func (c config) salesWeights() salesWeights {
+	return salesWeights{
+		Formula: c.SalesFormula,
+		Base:    c.SalesWeightBase,
+		Factor:  c.SalesWeightFactor,
+		Max:     c.SalesWeightMax,
+	}
+}
+
+// weight는 판매 건수로 completion 가중치를 계산합니다. log는 상위 몇 개 상품이 추천어를 독식하지 않도록 완만하게 올립니다.
+func (sw salesWeights) weight(count int64) int {
+	n := float64(count)
+	if sw.Formula == salesFormulaLog {
+		n = math.Log1p(n)
+	}
+	w := int(math.Round(sw.Base + sw.Factor*n))
+	if w < 0 {
+		w = 0
+	}
+	if sw.Max > 0 && w > sw.Max {
+		w = sw.Max
+	}
+	return w
+}
+
+func fetchSales(ctx context.Context, source string, from, to time.Time) ([]salesCount, error) {
+	u, err := url.Parse(source)
+	if err != nil {
+		return nil, fmt.Errorf("SALES_SOURCE_URL 파싱 실패: %w", err)
+	}
+	q := u.Query()
+	q.Set("from", from.UTC().Format(time.RFC3339))
+	q.Set("to", to.UTC().Format(time.RFC3339))
+	u.RawQuery = q.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("판매 집계 요청 생성 실패: %w", err)
+	}
+	if id := requestIDFrom(ctx); id != "" {
+		req.Header.Set(requestIDHeader, id)
+	}
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("판매 집계 요청 실패: %w", err)
+	}
+	defer discard(res.Body)
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("판매 집계 응답 코드: %d", res.StatusCode)
+	}
+	var parsed struct {
+		Items []salesCount `json:"items"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&parsed); err != nil {
+		return nil, fmt.Errorf("판매 집계 응답 파싱 실패: %w", err)
+	}
+	return parsed.Items, nil
+}
+
+// setWeights는 이미 있는 키워드의 가중치만 Bulk update로 바꿉니다. 판매 데이터에만 있는 키워드는
+// 추천어로 새로 만들지 않으며(document_missing) 건너뛴 건수로 집계합니다.
+func (s *store) setWeights(ctx context.Context, weights map[string]int) (updated, missing int, err error) {
+	var buf bytes.Buffer
+	for keyword, weight := range weights {
+		action := map[string]interface{}{
+			"update": map[string]interface{}{"_id": docID(keyword), "retry_on_conflict": updateRetries},
+		}
+		payload := map[string]interface{}{
+			"doc": map[string]interface{}{
+				"weight":  weight,
+				"suggest": map[string]interface{}{"weight": weight},
+			},
+		}
+		for _, line := range []interface{}{action, payload} {
+			b, err := json.Marshal(line)
+			if err != nil {
+				return 0, 0, fmt.Errorf("bulk 직렬화 실패: %w", err)
+			}
+			buf.Write(b)
+			buf.WriteByte('\n')
+		}
+	}
+
+	res, err := esapi.BulkRequest{Index: s.index, Body: &buf}.Do(ctx, s.client)
+	if err != nil {
+		return 0, 0, fmt.Errorf("bulk 요청 실패: %w", err)
+	}
+	defer discard(res.Body)
+	if res.IsError() {
+		return 0, 0, fmt.Errorf("bulk 응답 에러: %s", res.String())
+	}
+	var parsed struct {
+		Items []map[string]struct {
+			Status int `json:"status"`
+			Error  *struct {
+				Type string `json:"type"`
+			} `json:"error"`
+		} `json:"items"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&parsed); err != nil {
+		return 0, 0, fmt.Errorf("bulk 응답 파싱 실패: %w", err)
+	}
+	for _, item := range parsed.Items {
+		for _, result := range item {
+			switch {
+			case result.Error == nil:
+				updated++
+			case result.Status == http.StatusNotFound:
+				missing++
+			default:
+				return updated, missing, fmt.Errorf("가중치 갱신 실패: %s", result.Error.Type)
+			}
+		}
+	}
+	return updated, missing, nil
+}
+
+// syncSales는 최근 SALES_WINDOW 동안의 판매 건수를 받아 가중치를 다시 계산합니다.
+func (s *server) syncSales(ctx context.Context) (map[string]interface{}, error) {
+	to := time.Now()
+	items, err := fetchSales(ctx, s.cfg.SalesSourceURL, to.Add(-s.cfg.SalesWindow), to)
+	if err != nil {
+		return nil, err
+	}
+
+	formula := s.cfg.salesWeights()
+	batchSize := s.cfg.BulkBatchSize
+	if batchSize <= 0 {
+		batchSize = 500
+	}
+	var updated, missing int
+	batch := make(map[string]int, batchSize)
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		u, m, err := s.st.setWeights(ctx, batch)
+		updated, missing = updated+u, missing+m
+		if err != nil {
+			return err
+		}
+		weights := batch
+		s.mir.enqueue("sales", fmt.Sprintf("%d건", len(weights)), func(ctx context.Context, st *store) error {
+			_, _, err := st.setWeights(ctx, weights)
+			return err
+		})
+		batch = make(map[string]int, batchSize)
+		return nil
+	}
+	for _, item := range items {
+		keyword := canonicalKeyword(item.Keyword)
+		if keyword == "" {
+			continue
+		}
+		batch[keyword] = formula.weight(item.Count)
+		if len(batch) == batchSize {
+			if err := flush(); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if err := flush(); err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{
+		"received": len(items),
+		"updated":  updated,
+		"missing":  missing,
+		"window":   s.cfg.SalesWindow.String(),
+	}, nil
+}
+
+func (s *server) salesLoop(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			runCtx, cancel := context.WithTimeout(ctx, s.cfg.AdminTimeout)
+			result, err := s.syncSales(runCtx)
+			cancel()
+			if err != nil {
+				log.Printf("판매 기반 가중치 갱신 실패: %v", err)
+				continue
+			}
+			log.Printf("판매 기반 가중치 갱신: %v", result)
+		}
+	}
+}
+
+func (s *server) handleSalesSync(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		methodNotAllowed(w, r, http.MethodPost)
+		return
+	}
+	ctx, cancel := context.WithTimeout(r.Context(), s.cfg.AdminTimeout)
+	defer cancel()
+	result, err := s.syncSales(ctx)
+	if err != nil {
+		logf(r.Context(), "판매 기반 가중치 갱신 실패: %v", err)
+		writeError(w, r, codeUpsertFailed)
+		return
+	}
+	writeJSON(w, result)
+}
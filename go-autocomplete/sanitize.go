@@ -0,0 +1,55 @@
+package main
+
+import (
+	"net/http"
+	"strings"
+	"unicode"
+)
+
+const (
+	sanitizeStrip  = "strip"
+	sanitizeReject = "reject"
+)
+
+// disallowedRune은 completion 분석기를 깨뜨리거나 추천어에 보여선 안 되는 문자입니다.
+// 제어/서식 문자, 이모지를 포함한 기타 기호(So), 이모지 변형 선택자와 피부색 수식자가 해당됩니다.
+// 공백류는 canonicalKeyword가 한 칸으로 정리하므로 제외합니다.
+func disallowedRune(r rune) bool {
+	switch {
+	case unicode.IsSpace(r):
+		return false
+	case unicode.IsControl(r), unicode.In(r, unicode.Cf, unicode.Co, unicode.Cs, unicode.So, unicode.Me):
+		return true
+	case unicode.Is(unicode.Variation_Selector, r):
+		return true
+	case r >= 0x1F3FB && r <= 0x1F3FF:
+		return true
+	case r == unicode.ReplacementChar:
+		return true
+	}
+	return false
+}
+
+// stripDisallowed는 허용되지 않는 문자를 지운 결과와 지운 문자가 있었는지를 돌려줍니다.
+func stripDisallowed(s string) (string, bool) {
+	found := false
+	cleaned := strings.Map(func(r rune) rune {
+		if disallowedRune(r) {
+			found = true
+			return -1
+		}
+		return r
+	}, s)
+	return canonicalKeyword(cleaned), found
+}
+
+// sanitizeInput은 INPUT_SANITIZE 모드에 따라 입력을 정리합니다. reject 모드에서 허용되지 않는 문자가 있으면
+// 400을 쓰고 false를 돌려줍니다.
+func (s *server) sanitizeInput(w http.ResponseWriter, r *http.Request, value, field string) (string, bool) {
+	cleaned, found := stripDisallowed(canonicalKeyword(value))
+	if found && s.live().InputSanitize == sanitizeReject {
+		writeErrorDetails(w, r, codeInvalidCharacters, map[string]string{"field": field})
+		return "", false
+	}
+	return cleaned, true
+}
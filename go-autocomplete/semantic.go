@@ -0,0 +1,320 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"expvar"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/elastic/go-elasticsearch/v8/esapi"
+)
+
+var embeddingTotal = expvar.NewMap("embedding_total")
+
+// embedder는 외부 임베딩 서비스 클라이언트입니다. 서비스는 POST {"inputs": ["..."]}에
+// {"embeddings": [[0.1, ...], ...]}로 입력과 같은 순서의 벡터를 돌려줘야 합니다.
+type embedder struct {
+	url    string
+	dims   int
+	batch  int
+	client *http.Client
+}
+
+func newEmbedder(url string, dims, batch int, timeout time.Duration) *embedder {
+	return &embedder{url: url, dims: dims, batch: batch, client: &http.Client{Timeout: timeout}}
+}
+
+func (e *embedder) embed(ctx context.Context, inputs []string) ([][]float32, error) {
+	body, err := json.Marshal(map[string]interface{}{"inputs": inputs})
+	if err != nil {
+		return nil, fmt.Errorf("임베딩 요청 직렬화 실패: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, e.url, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("임베딩 요청 생성 실패: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if id := requestIDFrom(ctx); id != "" {
+		req.Header.Set(requestIDHeader, id)
+	}
+	res, err := e.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("임베딩 요청 실패: %w", err)
+	}
+	defer discard(res.Body)
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("임베딩 응답 코드: %d", res.StatusCode)
+	}
+	var parsed struct {
+		Embeddings [][]float32 `json:"embeddings"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&parsed); err != nil {
+		return nil, fmt.Errorf("임베딩 응답 파싱 실패: %w", err)
+	}
+	if len(parsed.Embeddings) != len(inputs) {
+		return nil, fmt.Errorf("임베딩 개수 불일치: %d/%d", len(parsed.Embeddings), len(inputs))
+	}
+	for _, v := range parsed.Embeddings {
+		if len(v) != e.dims {
+			return nil, fmt.Errorf("임베딩 차원 불일치: %d (EMBEDDING_DIMS %d)", len(v), e.dims)
+		}
+	}
+	return parsed.Embeddings, nil
+}
+
+// ensureVectorField는 기존 인덱스에 embedding 필드를 추가합니다. 새 필드 추가는 재색인 없이 가능하고,
+// 이미 다른 차원으로 매핑돼 있으면 ES가 거부합니다.
+func (s *store) ensureVectorField(ctx context.Context, dims int) error {
+	body, err := json.Marshal(map[string]interface{}{
+		"properties": map[string]interface{}{
+			"embedding": map[string]interface{}{"type": "dense_vector", "dims": dims, "index": true, "similarity": "cosine"},
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("매핑 직렬화 실패: %w", err)
+	}
+	res, err := esapi.IndicesPutMappingRequest{Index: []string{s.index}, Body: bytes.NewReader(body)}.Do(ctx, s.client)
+	if err != nil {
+		return fmt.Errorf("embedding 매핑 추가 실패: %w", err)
+	}
+	defer discard(res.Body)
+	if res.IsError() {
+		return fmt.Errorf("embedding 매핑 추가 응답 에러: %s", res.String())
+	}
+	return nil
+}
+
+type embedTarget struct {
+	ID   string
+	Text string
+}
+
+// missingEmbeddings는 아직 embedding이 없는 키워드를 n개까지 가져옵니다.
+func (s *store) missingEmbeddings(ctx context.Context, n int) ([]embedTarget, error) {
+	hits, err := s.searchSources(ctx, s.index, map[string]interface{}{
+		"size":    n,
+		"_source": []string{"keyword", "display"},
+		"query": map[string]interface{}{
+			"bool": map[string]interface{}{
+				"filter":   []interface{}{notExpiredQuery()},
+				"must_not": []interface{}{map[string]interface{}{"exists": map[string]interface{}{"field": "embedding"}}},
+			},
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	out := make([]embedTarget, 0, len(hits))
+	for _, h := range hits {
+		text, _ := h.Source["display"].(string)
+		if text == "" {
+			text, _ = h.Source["keyword"].(string)
+		}
+		if text != "" {
+			out = append(out, embedTarget{ID: h.ID, Text: text})
+		}
+	}
+	return out, nil
+}
+
+// setEmbeddings는 Bulk update로 벡터를 씁니다. 그 사이 삭제된 문서(document_missing)는 건너뜁니다.
+func (s *store) setEmbeddings(ctx context.Context, targets []embedTarget, vectors [][]float32) error {
+	var buf bytes.Buffer
+	for i, t := range targets {
+		action := map[string]interface{}{"update": map[string]interface{}{"_id": t.ID, "retry_on_conflict": updateRetries}}
+		payload := map[string]interface{}{"doc": map[string]interface{}{"embedding": vectors[i]}}
+		for _, line := range []interface{}{action, payload} {
+			b, err := json.Marshal(line)
+			if err != nil {
+				return fmt.Errorf("bulk 직렬화 실패: %w", err)
+			}
+			buf.Write(b)
+			buf.WriteByte('\n')
+		}
+	}
+	res, err := esapi.BulkRequest{Index: s.index, Body: &buf}.Do(ctx, s.client)
+	if err != nil {
+		return fmt.Errorf("bulk 요청 실패: %w", err)
+	}
+	defer discard(res.Body)
+	if res.IsError() {
+		return fmt.Errorf("bulk 응답 에러: %s", res.String())
+	}
+	var parsed struct {
+		Items []map[string]struct {
+			Error *struct {
+				Type string `json:"type"`
+			} `json:"error"`
+		} `json:"items"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&parsed); err != nil {
+		return fmt.Errorf("bulk 응답 파싱 실패: %w", err)
+	}
+	for _, item := range parsed.Items {
+		for _, result := range item {
+			if result.Error != nil && result.Error.Type != "document_missing_exception" {
+				return fmt.Errorf("embedding 쓰기 실패: %s", result.Error.Type)
+			}
+		}
+	}
+	return nil
+}
+
+// semanticSuggest는 질의 벡터와 가까운 키워드를 kNN으로 찾습니다. completion context의 채널은 검색 조건으로
+// 쓸 수 없으므로 여유 있게 가져와 _source의 채널로 거릅니다.
+func (s *store) semanticSuggest(ctx context.Context, vector []float32, channel string, minScore float64) ([]scoredSuggestion, error) {
+	k := suggestSize
+	if channel != "" {
+		k *= 2
+	}
+	var parsed struct {
+		Hits struct {
+			Hits []struct {
+				Score  float64 `json:"_score"`
+				Source struct {
+					Keyword    string                 `json:"keyword"`
+					Display    string                 `json:"display"`
+					Meta       map[string]interface{} `json:"meta"`
+					LastSeenAt *time.Time             `json:"last_seen_at"`
+					Suggest    struct {
+						Contexts struct {
+							Channel []string `json:"channel"`
+						} `json:"contexts"`
+					} `json:"suggest"`
+				} `json:"_source"`
+			} `json:"hits"`
+		} `json:"hits"`
+	}
+	body, err := json.Marshal(map[string]interface{}{
+		"knn": map[string]interface{}{
+			"field":          "embedding",
+			"query_vector":   vector,
+			"k":              k,
+			"num_candidates": k * 10,
+			"filter":         notExpiredQuery(),
+		},
+		"size":    k,
+		"_source": []string{"keyword", "display", "meta", "last_seen_at", "suggest.contexts"},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("쿼리 직렬화 실패: %w", err)
+	}
+	res, err := esapi.SearchRequest{Index: []string{s.index}, Body: bytes.NewReader(body)}.Do(ctx, s.client)
+	if err != nil {
+		return nil, fmt.Errorf("kNN 검색 요청 실패: %w", err)
+	}
+	defer discard(res.Body)
+	if res.IsError() {
+		return nil, fmt.Errorf("kNN 검색 응답 에러: %s", res.String())
+	}
+	if err := json.NewDecoder(res.Body).Decode(&parsed); err != nil {
+		return nil, fmt.Errorf("응답 파싱 실패: %w", err)
+	}
+
+	out := make([]scoredSuggestion, 0, suggestSize)
+	for _, h := range parsed.Hits.Hits {
+		if len(out) == suggestSize {
+			break
+		}
+		if h.Score < minScore {
+			continue
+		}
+		if chans := h.Source.Suggest.Contexts.Channel; channel != "" && len(chans) > 0 && !knownChannel(chans, channel) && !knownChannel(chans, channelAll) {
+			continue
+		}
+		text := h.Source.Display
+		if text == "" {
+			text = h.Source.Keyword
+		}
+		out = append(out, scoredSuggestion{Text: text, Weight: h.Score, Score: h.Score, Keyword: h.Source.Keyword, Meta: h.Source.Meta, LastSeenAt: h.Source.LastSeenAt})
+	}
+	return out, nil
+}
+
+func (s *server) suggestSemantic(ctx context.Context, locale, channel, q string) ([]scoredSuggestion, error) {
+	vectors, err := s.embed.embed(ctx, []string{q})
+	if err != nil {
+		return nil, err
+	}
+	return s.st.withLocale(locale).semanticSuggest(ctx, vectors[0], channel, s.cfg.SemanticMinScore)
+}
+
+// modeParam은 /suggest의 mode 파라미터를 읽습니다. semantic은 EMBEDDING_URL이 있을 때만 받습니다.
+func (s *server) modeParam(w http.ResponseWriter, r *http.Request) (semantic, ok bool) {
+	switch strings.TrimSpace(r.URL.Query().Get("mode")) {
+	case "", "prefix":
+		return false, true
+	case "semantic":
+		if s.embed != nil {
+			return true, true
+		}
+	}
+	invalidParameter(w, r, "mode")
+	return false, false
+}
+
+// embedPending은 로케일마다 embedding이 없는 키워드를 배치로 임베딩해 씁니다. 한 번에 maxBatches 배치까지만
+// 처리하고 나머지는 다음 주기로 넘깁니다.
+func (s *server) embedPending(ctx context.Context, maxBatches int) (int, error) {
+	total := 0
+	for _, locale := range s.cfg.locales() {
+		locale := locale
+		st := s.st.withLocale(locale)
+		for i := 0; i < maxBatches; i++ {
+			targets, err := st.missingEmbeddings(ctx, s.embed.batch)
+			if err != nil {
+				return total, err
+			}
+			if len(targets) == 0 {
+				break
+			}
+			texts := make([]string, len(targets))
+			for j, t := range targets {
+				texts[j] = t.Text
+			}
+			vectors, err := s.embed.embed(ctx, texts)
+			if err != nil {
+				embeddingTotal.Add("failed", int64(len(targets)))
+				return total, err
+			}
+			if err := st.setEmbeddings(ctx, targets, vectors); err != nil {
+				embeddingTotal.Add("failed", int64(len(targets)))
+				return total, err
+			}
+			s.mir.enqueue("embedding", locale, func(ctx context.Context, mst *store) error {
+				return mst.withLocale(locale).setEmbeddings(ctx, targets, vectors)
+			})
+			embeddingTotal.Add("embedded", int64(len(targets)))
+			total += len(targets)
+			if len(targets) < s.embed.batch {
+				break
+			}
+		}
+	}
+	return total, nil
+}
+
+func (s *server) embedLoop(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		runCtx, cancel := context.WithTimeout(ctx, s.cfg.AdminTimeout)
+		n, err := s.embedPending(runCtx, 20)
+		cancel()
+		if err != nil {
+			log.Printf("키워드 임베딩 실패: %v", err)
+		} else if n > 0 {
+			log.Printf("키워드 임베딩: %d건", n)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,477 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+	"unicode/utf8"
+)
+
+type server struct {
+	cfg    config
+	bg     context.Context
+	st     *store
+	reads  *readRouter
+	trie   *fallbackTrie
+	mir    *mirror
+	writer *bulkWriter
+	idem   *idempotency
+	snap   *snapshotter
+	jobs   *jobRegistry
+	bulk   bulkMode
+	hooks  *notifier
+	gens   *generations
+	dash   *dashboard
+	qlog   *queryLogger
+	shadow *shadowMirror
+	embed  *embedder
+	cache  *suggestCache
+	ready  atomic.Bool
+
+	cfgFile  *configFile
+	reloadMu sync.Mutex
+	tune     atomic.Pointer[tunables]
+}
+
+func (s *server) routes() *http.ServeMux {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte("ok"))
+	})
+	mux.HandleFunc("/readyz", s.handleReady)
+	mux.HandleFunc("/keywords", s.idem.wrap(s.handleUpsert))
+	mux.HandleFunc("/keywords/", s.handleKeyword)
+	mux.HandleFunc("/keywords/delete", s.handleBatchDelete)
+	mux.HandleFunc("/openapi.json", handleOpenAPI())
+	mux.HandleFunc("/suggest", s.handleSuggest)
+	mux.HandleFunc("/suggest/click", s.handleClick)
+	mux.Handle(adminPathPrefix, requireAdmin(s.cfg.AdminToken, s.adminRoutes()))
+	if s.qlog != nil {
+		mux.HandleFunc("/analytics/queries", s.handleQueryAnalytics)
+	}
+	return mux
+}
+
+// upsertWritten은 기본 클러스터에 반영된 업서트를 보조 클러스터와 웹훅으로 전파합니다.
+func (s *server) upsertWritten(req upsertRequest, created bool) {
+	s.mir.enqueue("upsert", req.Keyword, func(ctx context.Context, st *store) error {
+		_, err := st.withLocale(req.Locale).upsertKeyword(ctx, req)
+		return err
+	})
+	s.dash.recordIngested(1)
+	// 기존 문서 업서트도 가중치·표기가 바뀌거나 소프트 삭제된 키워드가 되살아나므로 updated로 알립니다.
+	if created {
+		s.hooks.publish(webhookEvent{Type: eventKeywordCreated, Keyword: canonicalKeyword(req.Keyword), Locale: req.Locale})
+	} else {
+		s.hooks.publish(webhookEvent{Type: eventKeywordUpdated, Reason: "upsert", Keyword: canonicalKeyword(req.Keyword), Locale: req.Locale})
+	}
+}
+
+func (s *server) handleUpsert(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		methodNotAllowed(w, r, http.MethodPost)
+		return
+	}
+	var req upsertRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, r, codeBadRequestBody)
+		return
+	}
+	var ok bool
+	if req.Keyword, ok = s.sanitizeInput(w, r, req.Keyword, "keyword"); !ok {
+		return
+	}
+	if req.Display, ok = s.sanitizeInput(w, r, req.Display, "display"); !ok {
+		return
+	}
+	if req.Keyword == "" {
+		writeError(w, r, codeEmptyKeyword)
+		return
+	}
+	if req.Romanize == nil {
+		romanize := s.cfg.RomanizeKeywords
+		req.Romanize = &romanize
+	}
+	// display는 같은 키워드의 표기만 바꿀 수 있습니다. 클릭 등 후속 요청이 표기로 들어와도 같은 문서를 찾도록 합니다.
+	if req.Display != "" && matchKey(req.Display) != matchKey(req.Keyword) {
+		writeErrorDetails(w, r, codeBadRequestBody, map[string]string{"field": "display"})
+		return
+	}
+	if req.Locale, ok = s.localeParam(w, r, req.Locale); !ok {
+		return
+	}
+	if !s.resolveChannels(w, r, &req) {
+		return
+	}
+	if s.writer != nil {
+		if err := s.writer.enqueue(req); err != nil {
+			writeError(w, r, codeWriteQueueFull)
+			return
+		}
+		w.WriteHeader(http.StatusAccepted)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), s.live().UpsertTimeout)
+	defer cancel()
+	created, err := s.st.withLocale(req.Locale).upsertKeyword(ctx, req)
+	if err != nil {
+		if errors.Is(err, errESSaturated) {
+			w.Header().Set("Retry-After", "1")
+			writeError(w, r, codeTooManyRequests)
+			return
+		}
+		logf(r.Context(), "upsert 실패: %v", err)
+		if errors.Is(err, context.DeadlineExceeded) {
+			writeError(w, r, codeUpstreamTimeout)
+			return
+		}
+		writeError(w, r, codeUpsertFailed)
+		return
+	}
+	s.upsertWritten(req, created)
+	w.WriteHeader(http.StatusCreated)
+}
+
+func (s *server) handleSuggest(w http.ResponseWriter, r *http.Request) {
+	q, ok := s.sanitizeInput(w, r, r.URL.Query().Get("q"), "q")
+	if !ok {
+		return
+	}
+	locale, ok := s.localeParam(w, r, r.URL.Query().Get("locale"))
+	if !ok {
+		return
+	}
+	channel, ok := s.channelParam(w, r, r.URL.Query().Get("channel"))
+	if !ok {
+		return
+	}
+	withProducts, ok := includeParam(w, r)
+	if !ok {
+		return
+	}
+	fields, ok := fieldsParam(w, r)
+	if !ok {
+		return
+	}
+	semantic, ok := s.modeParam(w, r)
+	if !ok {
+		return
+	}
+	if q == "" {
+		missingParameter(w, r, "q")
+		return
+	}
+	if min := s.live().minPrefixLength(q); utf8.RuneCountInString(q) < min {
+		writeJSON(w, suggestResponse{
+			Suggestions: []string{},
+			Hint:        &suggestHint{Reason: "prefix_too_short", MinLength: min},
+		})
+		return
+	}
+
+	start := time.Now()
+	etag := ""
+	// 상품 인덱스는 generation에 들어가지 않으므로 그 결과가 섞이는 응답에는 ETag를 붙이지 않습니다.
+	if gen, ok := s.gens.get(locale); ok && !(withProducts && s.cfg.ProductIndex != "") {
+		variant := channel
+		if withProducts {
+			variant += "|products"
+		}
+		if fields != nil {
+			variant += "|fields=" + fields.key()
+		}
+		// 설정을 다시 읽어 순위 계수나 오타 보정 설정이 바뀌면 같은 generation이라도 결과가 달라집니다.
+		if semantic {
+			variant += "|semantic"
+		} else {
+			if rk := s.live().ranking(); rk.enabled() {
+				variant += "|rank=" + rk.key()
+			}
+			if fk := s.live().fuzzyKey(); fk != "" {
+				variant += "|" + fk
+			}
+		}
+		etag = suggestETag(gen, locale, variant, q)
+		w.Header().Set("Cache-Control", "no-cache")
+		if etagMatches(r.Header.Get("If-None-Match"), etag) {
+			w.Header().Set("ETag", etag)
+			w.WriteHeader(http.StatusNotModified)
+			s.dash.recordSuggest(q, time.Since(start), true)
+			s.qlog.record(q, s.cfg.localeName(locale), channel, 0, true)
+			return
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), s.live().SuggestTimeout)
+	defer cancel()
+	var products chan []productSuggestion
+	if withProducts {
+		products = make(chan []productSuggestion, 1)
+		go func() {
+			found, err := s.st.withLocale(locale).suggestProducts(ctx, s.cfg, q)
+			if err != nil {
+				// 상품 추천은 부가 정보라 실패해도 키워드 추천은 내려줍니다.
+				logf(ctx, "상품 추천 조회 실패: %v", err)
+			}
+			products <- found
+		}()
+	}
+	var (
+		items     []scoredSuggestion
+		corrected string
+		err       error
+	)
+	if semantic {
+		items, err = s.suggestSemantic(ctx, locale, channel, q)
+	} else {
+		items, corrected, err = s.suggestPrefix(ctx, locale, channel, q)
+	}
+	if errors.Is(err, errESSaturated) {
+		w.Header().Set("Retry-After", "1")
+		writeError(w, r, codeServiceOverloaded)
+		return
+	}
+	if err != nil {
+		// fallback 트리는 기본 로케일 인덱스에서 채널을 구분하지 않고 만들므로, 채널을 지정한 요청에
+		// 쓰면 다른 채널 전용 키워드가 섞여 나갑니다.
+		fallback, ok := s.trie.suggest(q)
+		if locale != "" || channel != "" || semantic || !ok {
+			logf(r.Context(), "suggest 실패: %v", err)
+			if errors.Is(err, context.DeadlineExceeded) {
+				writeError(w, r, codeUpstreamTimeout)
+				return
+			}
+			writeError(w, r, codeSearchFailed)
+			return
+		}
+		logf(r.Context(), "suggest 실패, fallback 트리로 응답: %v", err)
+		w.Header().Set(fallbackHeader, "trie")
+		items = make([]scoredSuggestion, len(fallback))
+		for i, text := range fallback {
+			items[i] = scoredSuggestion{Text: text}
+		}
+		etag = ""
+	}
+	// 후보 인덱스 비교는 분석기 차이만 보도록 순위 재조정 전 결과로 합니다.
+	// 의미 기반 결과는 유사도 순서를 그대로 씁니다.
+	raw := suggestionTexts(topSuggestions(items, suggestSize))
+	if !semantic {
+		items = s.live().ranking().rerank(items, suggestSize, time.Now())
+	}
+	suggestions := suggestionTexts(items)
+	if etag != "" {
+		w.Header().Set("ETag", etag)
+	}
+	s.dash.recordSuggest(q, time.Since(start), false)
+	s.qlog.record(q, s.cfg.localeName(locale), channel, len(suggestions), false)
+	// 후보 인덱스는 기본 로케일 설정으로 만들고, fallback 트리와 오타 보정, 의미 기반 결과는 비교 대상이 아닙니다.
+	if locale == "" && err == nil && corrected == "" && !semantic {
+		s.shadow.observe(q, channel, raw)
+	}
+	resp := suggestResponse{Suggestions: suggestions, Corrected: corrected != "", CorrectedPrefix: corrected}
+	if products != nil {
+		resp.Products = <-products
+	}
+	if fields != nil {
+		// 필드를 고르면 suggestions가 문자열 대신 객체 배열이 되므로 응답 구조체 대신 map으로 씁니다.
+		payload := map[string]interface{}{"suggestions": fields.project(items)}
+		if resp.Corrected {
+			payload["corrected"], payload["corrected_prefix"] = true, corrected
+		}
+		if len(resp.Products) > 0 {
+			payload["products"] = resp.Products
+		}
+		writeJSON(w, payload)
+		return
+	}
+	writeJSON(w, resp)
+}
+
+// lookupPrefix는 접두어 suggester를 조회하고, 결과가 없으면 설정에 따라 오타를 허용해 한 번 더 찾습니다.
+// 보정한 결과를 쓰면 보정한 접두어를 함께 돌려줍니다.
+func (s *server) lookupPrefix(ctx context.Context, locale, channel, q string) ([]scoredSuggestion, string, error) {
+	items, err := s.reads.suggest(ctx, locale, channel, q, "")
+	tune := s.live()
+	if err != nil || len(items) > 0 || !tune.FuzzyRetry {
+		return items, "", err
+	}
+	fixed, err := s.reads.suggest(ctx, locale, channel, q, tune.Fuzziness)
+	switch {
+	case err != nil:
+		// 오타 보정은 부가 기능이라 실패해도 빈 결과를 그대로 내려줍니다.
+		logf(ctx, "오타 보정 재조회 실패: %v", err)
+		suggestCorrected.Add("error", 1)
+	case len(fixed) > 0:
+		suggestCorrected.Add("corrected", 1)
+		return fixed, correctedPrefix(q, fixed[0].Text), nil
+	default:
+		suggestCorrected.Add("empty", 1)
+	}
+	return items, "", nil
+}
+
+func (s *server) handleDeadLetters(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, map[string]interface{}{"dead_letters": s.mir.deadLetterSnapshot()})
+}
+
+func (s *server) handleMirrorReplay(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		methodNotAllowed(w, r, http.MethodPost)
+		return
+	}
+	writeJSON(w, map[string]interface{}{"requeued": s.mir.replay()})
+}
+
+func (s *server) handleJob(w http.ResponseWriter, r *http.Request) {
+	id := strings.TrimPrefix(r.URL.Path, "/admin/jobs/")
+	j, ok := s.jobs.get(id)
+	if !ok {
+		writeError(w, r, codeJobNotFound)
+		return
+	}
+	writeJSON(w, j)
+}
+
+func (s *server) handleSnapshot(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		methodNotAllowed(w, r, http.MethodPost)
+		return
+	}
+	ctx, cancel := context.WithTimeout(r.Context(), s.cfg.AdminTimeout)
+	defer cancel()
+	res, err := s.snap.snapshot(ctx)
+	if errors.Is(err, errSnapshotRunning) {
+		writeError(w, r, codeSnapshotInProgress)
+		return
+	}
+	if err != nil {
+		logf(r.Context(), "스냅샷 실패: %v", err)
+		writeError(w, r, codeSnapshotFailed)
+		return
+	}
+	writeJSON(w, res)
+}
+
+func (s *server) handleRestore(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		methodNotAllowed(w, r, http.MethodPost)
+		return
+	}
+	key := strings.TrimSpace(r.URL.Query().Get("snapshot"))
+	if key == "" {
+		missingParameter(w, r, "snapshot")
+		return
+	}
+	if !s.snap.restoring.TryLock() {
+		writeError(w, r, codeRestoreInProgress)
+		return
+	}
+	id := s.jobs.start("restore")
+	go func() {
+		defer s.snap.restoring.Unlock()
+		result, err := s.snap.restore(s.bg, key, s.jobs, id)
+		if err != nil {
+			logf(r.Context(), "스냅샷 복원 실패: %v", err)
+		}
+		s.jobs.finish(id, result, err)
+	}()
+	writeJSONStatus(w, http.StatusAccepted, map[string]interface{}{"job_id": id, "status_url": "/admin/jobs/" + id})
+}
+
+type clickRequest struct {
+	Keyword string `json:"keyword" validate:"required"`
+	Locale  string `json:"locale,omitempty"`
+}
+
+func (s *server) handleClick(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		methodNotAllowed(w, r, http.MethodPost)
+		return
+	}
+	var req clickRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, r, codeBadRequestBody)
+		return
+	}
+	keyword, ok := s.sanitizeInput(w, r, req.Keyword, "keyword")
+	if !ok {
+		return
+	}
+	if keyword == "" {
+		writeError(w, r, codeEmptyKeyword)
+		return
+	}
+	locale, ok := s.localeParam(w, r, req.Locale)
+	if !ok {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), s.live().UpsertTimeout)
+	defer cancel()
+	halfLife := s.live().RankClickHalfLife
+	err := s.st.withLocale(locale).recordClick(ctx, keyword, halfLife)
+	if errors.Is(err, errKeywordNotFound) {
+		writeError(w, r, codeKeywordNotFound)
+		return
+	}
+	if err != nil {
+		logf(r.Context(), "클릭 기록 실패: %v", err)
+		writeError(w, r, codeUpsertFailed)
+		return
+	}
+	s.mir.enqueue("click", keyword, func(ctx context.Context, st *store) error {
+		return st.withLocale(locale).recordClick(ctx, keyword, halfLife)
+	})
+	s.dash.recordClick(keyword)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *server) handleGC(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		methodNotAllowed(w, r, http.MethodPost)
+		return
+	}
+	olderThan := s.cfg.GCOlderThan
+	if v := strings.TrimSpace(r.URL.Query().Get("older_than")); v != "" {
+		d, err := parseAge(v)
+		if err != nil {
+			invalidParameter(w, r, "older_than")
+			return
+		}
+		olderThan = d
+	}
+	if olderThan <= 0 {
+		missingParameter(w, r, "older_than")
+		return
+	}
+	dryRun := r.URL.Query().Get("dry_run") == "true"
+
+	ctx, cancel := context.WithTimeout(r.Context(), s.cfg.AdminTimeout)
+	defer cancel()
+	var (
+		n   int
+		err error
+	)
+	if dryRun {
+		n, err = s.countStale(ctx, time.Now().Add(-olderThan))
+	} else {
+		n, err = s.gc(ctx, olderThan)
+	}
+	if err != nil {
+		logf(r.Context(), "비활성 키워드 정리 실패: %v", err)
+		writeError(w, r, codeGCFailed)
+		return
+	}
+	writeJSON(w, map[string]interface{}{
+		"older_than": olderThan.String(),
+		"dry_run":    dryRun,
+		"matched":    n,
+	})
+}
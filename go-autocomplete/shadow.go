@@ -0,0 +1,258 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"expvar"
+	"fmt"
+	"log"
+	"math/rand"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/elastic/go-elasticsearch/v8/esapi"
+)
+
+const (
+	shadowTimeout     = 2 * time.Second
+	shadowRecentDiffs = 20
+)
+
+var shadowCompared = expvar.NewMap("shadow_compare_total")
+
+// shadowSettings는 후보 인덱스의 분석 설정입니다. 지정하지 않은 값은 운영 인덱스 설정을 따릅니다.
+func (c config) shadowSettings() indexSettings {
+	is := c.indexSettings()
+	if c.ShadowTokenizer != "" {
+		is.Tokenizer = c.ShadowTokenizer
+	}
+	if c.ShadowMinGram > 0 {
+		is.MinGram = c.ShadowMinGram
+	}
+	if c.ShadowMaxGram > 0 {
+		is.MaxGram = c.ShadowMaxGram
+	}
+	return is
+}
+
+// withIndex는 같은 클라이언트로 다른 인덱스와 설정을 다루는 store를 돌려줍니다.
+func (s *store) withIndex(name string, settings indexSettings) *store {
+	ss := *s
+	ss.index = name
+	ss.settings = settings
+	return &ss
+}
+
+type shadowQuery struct {
+	q       string
+	channel string
+	primary []string
+}
+
+type shadowDiff struct {
+	Query   string    `json:"q"`
+	Primary []string  `json:"primary"`
+	Shadow  []string  `json:"shadow"`
+	At      time.Time `json:"at"`
+}
+
+// shadowMirror는 기본 로케일 suggest 요청 일부를 후보 인덱스에 비동기로 다시 보내 결과를 비교합니다.
+// 응답 경로를 막지 않도록 큐가 차면 버립니다.
+type shadowMirror struct {
+	st    *store
+	rate  float64
+	queue chan shadowQuery
+
+	mu        sync.Mutex
+	compared  int64
+	identical int64
+	top1      int64
+	overlap   float64
+	recent    []shadowDiff
+}
+
+func newShadowMirror(st *store, rate float64, queueSize int) *shadowMirror {
+	return &shadowMirror{st: st, rate: rate, queue: make(chan shadowQuery, queueSize)}
+}
+
+func (sm *shadowMirror) observe(q, channel string, primary []string) {
+	if sm == nil || rand.Float64() >= sm.rate {
+		return
+	}
+	select {
+	case sm.queue <- shadowQuery{q: q, channel: channel, primary: primary}:
+	default:
+		shadowCompared.Add("dropped", 1)
+	}
+}
+
+func (sm *shadowMirror) run(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case sq := <-sm.queue:
+			qctx, cancel := context.WithTimeout(ctx, shadowTimeout)
+			shadow, err := sm.st.suggest(qctx, sq.q, sq.channel, "")
+			cancel()
+			if err != nil {
+				shadowCompared.Add("error", 1)
+				continue
+			}
+			sm.compare(sq, suggestionTexts(topSuggestions(shadow, suggestSize)))
+		}
+	}
+}
+
+// compare는 두 결과를 identical(순서까지 같음), reordered(집합만 같음), different로 나누고
+// 겹치는 비율(Jaccard)과 1순위 일치 여부를 누적합니다.
+func (sm *shadowMirror) compare(sq shadowQuery, shadow []string) {
+	inPrimary := make(map[string]bool, len(sq.primary))
+	for _, s := range sq.primary {
+		inPrimary[s] = true
+	}
+	common := 0
+	for _, s := range shadow {
+		if inPrimary[s] {
+			common++
+		}
+	}
+	union := len(sq.primary) + len(shadow) - common
+	overlap := 1.0
+	if union > 0 {
+		overlap = float64(common) / float64(union)
+	}
+
+	kind := "different"
+	switch {
+	case equalStrings(sq.primary, shadow):
+		kind = "identical"
+	case common == len(sq.primary) && common == len(shadow):
+		kind = "reordered"
+	}
+	shadowCompared.Add(kind, 1)
+
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+	sm.compared++
+	sm.overlap += overlap
+	if kind == "identical" {
+		sm.identical++
+	}
+	bothEmpty := len(sq.primary) == 0 && len(shadow) == 0
+	if bothEmpty || (len(sq.primary) > 0 && len(shadow) > 0 && sq.primary[0] == shadow[0]) {
+		sm.top1++
+	}
+	if kind == "different" {
+		sm.recent = append(sm.recent, shadowDiff{Query: sq.q, Primary: sq.primary, Shadow: shadow, At: time.Now().UTC()})
+		if len(sm.recent) > shadowRecentDiffs {
+			sm.recent = sm.recent[len(sm.recent)-shadowRecentDiffs:]
+		}
+	}
+}
+
+func equalStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func (sm *shadowMirror) report() map[string]interface{} {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+	out := map[string]interface{}{
+		"index":        sm.st.index,
+		"settings":     sm.st.settings,
+		"sample_rate":  sm.rate,
+		"compared":     sm.compared,
+		"recent_diffs": append([]shadowDiff(nil), sm.recent...),
+	}
+	if sm.compared > 0 {
+		n := float64(sm.compared)
+		out["identical_rate"] = float64(sm.identical) / n
+		out["top1_agreement"] = float64(sm.top1) / n
+		out["mean_overlap"] = sm.overlap / n
+	}
+	return out
+}
+
+// rebuildShadow는 후보 인덱스를 현재 shadow 설정으로 다시 만들고 운영 인덱스를 _reindex로 복사합니다.
+// completion 입력은 색인 시점에 분석되므로 재색인만으로 새 분석기가 적용됩니다. 복사는 ES 태스크로 돌고 태스크 ID를 돌려줍니다.
+func (s *store) rebuildShadow(ctx context.Context, shadow *store) (string, error) {
+	res, err := esapi.IndicesDeleteRequest{Index: []string{shadow.index}}.Do(ctx, s.client)
+	if err != nil {
+		return "", fmt.Errorf("후보 인덱스 삭제 실패: %w", err)
+	}
+	discard(res.Body)
+	if res.IsError() && res.StatusCode != http.StatusNotFound {
+		return "", fmt.Errorf("후보 인덱스 삭제 응답 에러: %s", res.String())
+	}
+	mapping, err := shadow.settings.render()
+	if err != nil {
+		return "", err
+	}
+	if err := s.createIndex(ctx, shadow.index, mapping); err != nil {
+		return "", err
+	}
+
+	body, err := json.Marshal(map[string]interface{}{
+		"source": map[string]interface{}{"index": s.index},
+		"dest":   map[string]interface{}{"index": shadow.index},
+	})
+	if err != nil {
+		return "", fmt.Errorf("재색인 요청 직렬화 실패: %w", err)
+	}
+	wait, refresh := false, true
+	res, err = esapi.ReindexRequest{Body: bytes.NewReader(body), WaitForCompletion: &wait, Refresh: &refresh}.Do(ctx, s.client)
+	if err != nil {
+		return "", fmt.Errorf("재색인 요청 실패: %w", err)
+	}
+	defer discard(res.Body)
+	if res.IsError() {
+		return "", fmt.Errorf("재색인 응답 에러: %s", res.String())
+	}
+	var parsed struct {
+		Task string `json:"task"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&parsed); err != nil {
+		return "", fmt.Errorf("응답 파싱 실패: %w", err)
+	}
+	return parsed.Task, nil
+}
+
+func (s *server) handleShadow(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		methodNotAllowed(w, r, http.MethodGet)
+		return
+	}
+	writeJSON(w, s.shadow.report())
+}
+
+func (s *server) handleShadowRebuild(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		methodNotAllowed(w, r, http.MethodPost)
+		return
+	}
+	ctx, cancel := context.WithTimeout(r.Context(), s.cfg.AdminTimeout)
+	defer cancel()
+	task, err := s.st.rebuildShadow(ctx, s.shadow.st)
+	if err != nil {
+		logf(r.Context(), "후보 인덱스 재구성 실패: %v", err)
+		writeError(w, r, codeIndexSettingsFailed)
+		return
+	}
+	log.Printf("후보 인덱스 재구성 시작: %s (task %s)", s.shadow.st.index, task)
+	writeJSONStatus(w, http.StatusAccepted, map[string]interface{}{
+		"index":      s.shadow.st.index,
+		"task":       task,
+		"status_url": "/_tasks/" + task,
+	})
+}
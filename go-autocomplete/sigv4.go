@@ -0,0 +1,79 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+	v4 "github.com/aws/aws-sdk-go-v2/signer/v4"
+)
+
+// sigv4Transport는 Amazon OpenSearch Service의 IAM 인증을 위해 요청마다 SigV4 서명을 붙입니다.
+type sigv4Transport struct {
+	next    http.RoundTripper
+	creds   aws.CredentialsProvider
+	signer  *v4.Signer
+	region  string
+	service string
+}
+
+func newSigV4Transport(ctx context.Context, region, service string) (*sigv4Transport, error) {
+	var opts []func(*awsconfig.LoadOptions) error
+	if region != "" {
+		opts = append(opts, awsconfig.WithRegion(region))
+	}
+	awsCfg, err := awsconfig.LoadDefaultConfig(ctx, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("AWS 설정 로드 실패: %w", err)
+	}
+	if awsCfg.Region == "" {
+		return nil, errors.New("AWS 리전을 확인할 수 없음")
+	}
+	if awsCfg.Credentials == nil {
+		return nil, errors.New("AWS 자격 증명을 찾을 수 없음")
+	}
+	return &sigv4Transport{
+		next:    http.DefaultTransport,
+		creds:   awsCfg.Credentials,
+		signer:  v4.NewSigner(),
+		region:  awsCfg.Region,
+		service: service,
+	}, nil
+}
+
+func (t *sigv4Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+	creds, err := t.creds.Retrieve(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("AWS 자격 증명 조회 실패: %w", err)
+	}
+
+	var payload []byte
+	if req.Body != nil && req.Body != http.NoBody {
+		payload, err = io.ReadAll(req.Body)
+		_ = req.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("요청 본문 읽기 실패: %w", err)
+		}
+	}
+	sum := sha256.Sum256(payload)
+	payloadHash := hex.EncodeToString(sum[:])
+
+	signed := req.Clone(ctx)
+	signed.Body = io.NopCloser(bytes.NewReader(payload))
+	signed.ContentLength = int64(len(payload))
+	signed.Header.Del("Authorization")
+	signed.Header.Set("X-Amz-Content-Sha256", payloadHash)
+	if err := t.signer.SignHTTP(ctx, creds, signed, payloadHash, t.service, t.region, time.Now()); err != nil {
+		return nil, fmt.Errorf("SigV4 서명 실패: %w", err)
+	}
+	return t.next.RoundTrip(signed)
+}
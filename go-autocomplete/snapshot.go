@@ -0,0 +1,180 @@
+package main
+
+import (
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+const snapshotKeyLayout = "20060102T150405Z"
+
+var errSnapshotRunning = errors.New("스냅샷이 이미 진행 중")
+
+// snapshotter는 전체 키워드를 gzip NDJSON으로 내보내 S3 호환 스토리지에 보관합니다.
+// ES 스냅샷과 별개인 재해 복구용이며, 최근 retain개만 남깁니다. 모든 로케일 인덱스를 한 파일에 담고
+// 줄마다 로케일을 적습니다.
+type snapshotter struct {
+	st      *store
+	s3      *s3.Client
+	bucket  string
+	prefix  string
+	retain  int
+	locales []string
+
+	running sync.Mutex
+	// restoring은 복원 작업이 도는 동안 잠겨 있어 별칭 교체가 겹치지 않게 합니다.
+	restoring sync.Mutex
+}
+
+type snapshotResult struct {
+	Key     string         `json:"key"`
+	Count   int            `json:"count"`
+	Indices map[string]int `json:"indices"`
+}
+
+func newSnapshotter(ctx context.Context, st *store, cfg config) (*snapshotter, error) {
+	awsCfg, err := awsconfig.LoadDefaultConfig(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("AWS 설정 로드 실패: %w", err)
+	}
+	client := s3.NewFromConfig(awsCfg, func(o *s3.Options) {
+		if cfg.SnapshotEndpoint != "" {
+			o.BaseEndpoint = aws.String(cfg.SnapshotEndpoint)
+		}
+		o.UsePathStyle = cfg.SnapshotPathStyle
+	})
+	return &snapshotter{
+		st:      st,
+		s3:      client,
+		bucket:  cfg.SnapshotBucket,
+		prefix:  cfg.SnapshotPrefix,
+		retain:  cfg.SnapshotRetain,
+		locales: cfg.locales(),
+	}, nil
+}
+
+func (sn *snapshotter) run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			res, err := sn.snapshot(ctx)
+			if err != nil {
+				log.Printf("정기 스냅샷 실패: %v", err)
+				continue
+			}
+			log.Printf("정기 스냅샷 완료: %s (%d건)", res.Key, res.Count)
+		}
+	}
+}
+
+func (sn *snapshotter) snapshot(ctx context.Context) (snapshotResult, error) {
+	if !sn.running.TryLock() {
+		return snapshotResult{}, errSnapshotRunning
+	}
+	defer sn.running.Unlock()
+
+	tmp, err := os.CreateTemp("", "autocomplete-snapshot-*.ndjson.gz")
+	if err != nil {
+		return snapshotResult{}, fmt.Errorf("임시 파일 생성 실패: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	count := 0
+	indices := make(map[string]int, len(sn.locales))
+	gz := gzip.NewWriter(tmp)
+	enc := json.NewEncoder(gz)
+	for _, locale := range sn.locales {
+		st := sn.st.withLocale(locale)
+		err = st.scan(ctx, func(doc storedDoc) error {
+			count++
+			indices[st.index]++
+			doc.Locale = locale
+			return enc.Encode(doc)
+		})
+		if err != nil {
+			return snapshotResult{}, fmt.Errorf("키워드 내보내기 실패 (%s): %w", st.index, err)
+		}
+	}
+	if err := gz.Close(); err != nil {
+		return snapshotResult{}, fmt.Errorf("gzip 종료 실패: %w", err)
+	}
+	if _, err := tmp.Seek(0, 0); err != nil {
+		return snapshotResult{}, fmt.Errorf("임시 파일 되감기 실패: %w", err)
+	}
+
+	key := sn.prefix + "keywords-" + time.Now().UTC().Format(snapshotKeyLayout) + ".ndjson.gz"
+	_, err = sn.s3.PutObject(ctx, &s3.PutObjectInput{
+		Bucket:          aws.String(sn.bucket),
+		Key:             aws.String(key),
+		Body:            tmp,
+		ContentType:     aws.String("application/x-ndjson"),
+		ContentEncoding: aws.String("gzip"),
+	})
+	if err != nil {
+		return snapshotResult{}, fmt.Errorf("스냅샷 업로드 실패: %w", err)
+	}
+
+	if err := sn.prune(ctx); err != nil {
+		log.Printf("오래된 스냅샷 정리 실패: %v", err)
+	}
+	return snapshotResult{Key: key, Count: count, Indices: indices}, nil
+}
+
+func (sn *snapshotter) list(ctx context.Context) ([]string, error) {
+	var keys []string
+	p := s3.NewListObjectsV2Paginator(sn.s3, &s3.ListObjectsV2Input{
+		Bucket: aws.String(sn.bucket),
+		Prefix: aws.String(sn.prefix + "keywords-"),
+	})
+	for p.HasMorePages() {
+		page, err := p.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("스냅샷 목록 조회 실패: %w", err)
+		}
+		for _, obj := range page.Contents {
+			if key := aws.ToString(obj.Key); strings.HasSuffix(key, ".ndjson.gz") {
+				keys = append(keys, key)
+			}
+		}
+	}
+	sort.Strings(keys)
+	return keys, nil
+}
+
+func (sn *snapshotter) prune(ctx context.Context) error {
+	if sn.retain <= 0 {
+		return nil
+	}
+	keys, err := sn.list(ctx)
+	if err != nil {
+		return err
+	}
+	for len(keys) > sn.retain {
+		_, err := sn.s3.DeleteObject(ctx, &s3.DeleteObjectInput{
+			Bucket: aws.String(sn.bucket),
+			Key:    aws.String(keys[0]),
+		})
+		if err != nil {
+			return fmt.Errorf("스냅샷 삭제 실패 (%s): %w", keys[0], err)
+		}
+		keys = keys[1:]
+	}
+	return nil
+}
@@ -0,0 +1,267 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/elastic/go-elasticsearch/v8/esapi"
+)
+
+// 소프트 삭제는 suggest 입력을 tombstone_input으로 옮기고 deleted_at을 남깁니다. completion 필드에서 입력이
+// 빠지므로 추천에서 사라지고, 채널 context와 가중치는 그대로 두어 복원하면 원래 상태로 돌아옵니다.
+// 이미 삭제된 문서는 noop이라 deleted_at(보관 기간 기준 시각)이 밀리지 않습니다.
+const (
+	softDeleteScript = `if (ctx._source.deleted_at != null) { ctx.op = 'noop'; return; }
+ctx._source.tombstone_input = ctx._source.suggest.input;
+ctx._source.suggest.input = [];
+ctx._source.deleted_at = params.now;`
+	restoreScript = `if (ctx._source.deleted_at == null) { ctx.op = 'noop'; return; }
+ctx._source.suggest.input = ctx._source.remove('tombstone_input');
+ctx._source.remove('deleted_at');`
+)
+
+func softDeleteBody() map[string]interface{} {
+	return map[string]interface{}{
+		"script": map[string]interface{}{
+			"lang":   "painless",
+			"source": softDeleteScript,
+			"params": map[string]interface{}{"now": time.Now().UTC().Format(time.RFC3339)},
+		},
+	}
+}
+
+// notDeleted는 query에 소프트 삭제되지 않은 문서 조건을 더합니다.
+func notDeleted(query map[string]interface{}) map[string]interface{} {
+	return map[string]interface{}{
+		"bool": map[string]interface{}{
+			"filter":   query,
+			"must_not": map[string]interface{}{"exists": map[string]interface{}{"field": "deleted_at"}},
+		},
+	}
+}
+
+// softDeleteByQuery는 query에 맞는 살아 있는 문서를 update-by-query로 소프트 삭제하고 건수를 돌려줍니다.
+func (s *store) softDeleteByQuery(ctx context.Context, query map[string]interface{}) (int, error) {
+	body := softDeleteBody()
+	body["query"] = notDeleted(query)
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return 0, fmt.Errorf("쿼리 직렬화 실패: %w", err)
+	}
+	res, err := esapi.UpdateByQueryRequest{
+		Index:     []string{s.index},
+		Body:      bytes.NewReader(payload),
+		Conflicts: "proceed",
+	}.Do(ctx, s.client)
+	if err != nil {
+		return 0, fmt.Errorf("update-by-query 요청 실패: %w", err)
+	}
+	defer discard(res.Body)
+	if res.IsError() {
+		return 0, fmt.Errorf("update-by-query 응답 에러: %s", res.String())
+	}
+	var parsed struct {
+		Updated int `json:"updated"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&parsed); err != nil {
+		return 0, fmt.Errorf("응답 파싱 실패: %w", err)
+	}
+	return parsed.Updated, nil
+}
+
+// softDeleteIDs는 deleteIDs의 소프트 삭제판입니다. 없는 문서와 이미 삭제된 문서는 not_found입니다.
+func (s *store) softDeleteIDs(ctx context.Context, ids []string) ([]batchDeleteItem, error) {
+	script := softDeleteBody()
+	var buf bytes.Buffer
+	for _, id := range ids {
+		for _, line := range []interface{}{
+			map[string]interface{}{"update": map[string]interface{}{"_id": id, "retry_on_conflict": updateRetries}},
+			script,
+		} {
+			b, err := json.Marshal(line)
+			if err != nil {
+				return nil, fmt.Errorf("bulk 직렬화 실패: %w", err)
+			}
+			buf.Write(b)
+			buf.WriteByte('\n')
+		}
+	}
+	res, err := esapi.BulkRequest{Index: s.index, Body: &buf}.Do(ctx, s.client)
+	if err != nil {
+		return nil, fmt.Errorf("bulk 요청 실패: %w", err)
+	}
+	defer discard(res.Body)
+	if res.IsError() {
+		return nil, fmt.Errorf("bulk 응답 에러: %s", res.String())
+	}
+	var parsed struct {
+		Items []map[string]struct {
+			Status int             `json:"status"`
+			Result string          `json:"result"`
+			Error  json.RawMessage `json:"error"`
+		} `json:"items"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&parsed); err != nil {
+		return nil, fmt.Errorf("bulk 응답 파싱 실패: %w", err)
+	}
+	if len(parsed.Items) != len(ids) {
+		return nil, fmt.Errorf("bulk 응답 항목 수 불일치: %d/%d", len(parsed.Items), len(ids))
+	}
+	out := make([]batchDeleteItem, len(ids))
+	for i, item := range parsed.Items {
+		out[i].ID = ids[i]
+		for _, result := range item {
+			switch {
+			case result.Status == http.StatusNotFound || result.Result == "noop":
+				out[i].Result = "not_found"
+			case len(result.Error) > 0:
+				out[i].Result, out[i].Error = "error", string(result.Error)
+			default:
+				out[i].Result = "deleted"
+			}
+		}
+	}
+	return out, nil
+}
+
+// updateScript는 문서 하나에 스크립트를 적용합니다. 문서가 없거나 스크립트가 noop이면 errKeywordNotFound입니다.
+func (s *store) updateScript(ctx context.Context, keyword string, body map[string]interface{}) error {
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return fmt.Errorf("payload 직렬화 실패: %w", err)
+	}
+	retries := updateRetries
+	res, err := esapi.UpdateRequest{
+		Index:           s.index,
+		DocumentID:      docID(keyword),
+		Body:            bytes.NewReader(payload),
+		RetryOnConflict: &retries,
+	}.Do(ctx, s.client)
+	if err != nil {
+		return fmt.Errorf("업데이트 요청 실패: %w", err)
+	}
+	defer discard(res.Body)
+	if res.StatusCode == http.StatusNotFound {
+		return errKeywordNotFound
+	}
+	if res.IsError() {
+		return fmt.Errorf("업데이트 응답 에러: %s", res.String())
+	}
+	var parsed struct {
+		Result string `json:"result"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&parsed); err != nil {
+		return fmt.Errorf("응답 파싱 실패: %w", err)
+	}
+	if parsed.Result == "noop" {
+		return errKeywordNotFound
+	}
+	return nil
+}
+
+func (s *store) softDeleteKeyword(ctx context.Context, keyword string) error {
+	return s.updateScript(ctx, keyword, softDeleteBody())
+}
+
+// restoreKeyword는 소프트 삭제된 키워드를 되살립니다. 삭제되지 않은 키워드도 errKeywordNotFound입니다.
+func (s *store) restoreKeyword(ctx context.Context, keyword string) error {
+	return s.updateScript(ctx, keyword, map[string]interface{}{
+		"script": map[string]interface{}{"lang": "painless", "source": restoreScript},
+	})
+}
+
+// purgeDeleted는 보관 기간이 지난 tombstone을 실제로 지웁니다.
+func (s *store) purgeDeleted(ctx context.Context, retention time.Duration) (int, error) {
+	return s.deleteByQuery(ctx, map[string]interface{}{
+		"range": map[string]interface{}{
+			"deleted_at": map[string]interface{}{"lt": time.Now().Add(-retention).UTC().Format(time.RFC3339)},
+		},
+	})
+}
+
+func (s *server) softDelete() bool {
+	return s.cfg.SoftDeleteRetention > 0
+}
+
+// removeKeyword는 설정에 따라 키워드 하나를 소프트 또는 실제 삭제합니다.
+func (s *server) removeKeyword(ctx context.Context, st *store, keyword string) error {
+	if s.softDelete() {
+		return st.softDeleteKeyword(ctx, keyword)
+	}
+	return st.deleteKeyword(ctx, keyword)
+}
+
+func (s *server) removeIDs(ctx context.Context, st *store, ids []string) ([]batchDeleteItem, error) {
+	if s.softDelete() {
+		return st.softDeleteIDs(ctx, ids)
+	}
+	return st.deleteIDs(ctx, ids)
+}
+
+func (s *server) removeByQuery(ctx context.Context, st *store, query map[string]interface{}) (int, error) {
+	if s.softDelete() {
+		return st.softDeleteByQuery(ctx, query)
+	}
+	return st.deleteByQuery(ctx, query)
+}
+
+func (s *server) purgeTombstones(ctx context.Context) {
+	for _, locale := range s.cfg.locales() {
+		locale := locale
+		ls := s.st.withLocale(locale)
+		purged, err := ls.purgeDeleted(ctx, s.cfg.SoftDeleteRetention)
+		if err != nil {
+			log.Printf("삭제 보관 기간 지난 키워드 정리 실패 (%s): %v", ls.index, err)
+			continue
+		}
+		if purged > 0 {
+			log.Printf("삭제 보관 기간 지난 키워드 %d건 영구 삭제 (%s)", purged, ls.index)
+		}
+		s.mir.enqueue("purge-deleted", ls.index, func(ctx context.Context, st *store) error {
+			_, err := st.withLocale(locale).purgeDeleted(ctx, s.cfg.SoftDeleteRetention)
+			return err
+		})
+	}
+}
+
+func (s *server) handleRestoreKeyword(w http.ResponseWriter, r *http.Request, locale, keyword string) {
+	if r.Method != http.MethodPost {
+		methodNotAllowed(w, r, http.MethodPost)
+		return
+	}
+	ctx, cancel := context.WithTimeout(r.Context(), s.live().UpsertTimeout)
+	defer cancel()
+	err := s.st.withLocale(locale).restoreKeyword(ctx, keyword)
+	if errors.Is(err, errKeywordNotFound) {
+		writeError(w, r, codeKeywordNotFound)
+		return
+	}
+	if err != nil {
+		logf(r.Context(), "키워드 복원 실패: %v", err)
+		writeError(w, r, codeUpsertFailed)
+		return
+	}
+	s.mir.enqueue("restore", keyword, func(ctx context.Context, st *store) error {
+		if err := st.withLocale(locale).restoreKeyword(ctx, keyword); err != nil && !errors.Is(err, errKeywordNotFound) {
+			return err
+		}
+		return nil
+	})
+	s.hooks.publish(webhookEvent{Type: eventKeywordUpdated, Reason: "restore", Keyword: keyword, Locale: locale})
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// restoreSuffix는 /keywords/{keyword}/restore 경로의 접미사입니다. 키워드 안의 '/'는 %2F로 인코딩되므로
+// 이스케이프된 경로에서 잘라내면 키워드와 헷갈리지 않습니다.
+const restoreSuffix = "/restore"
+
+func isRestorePath(r *http.Request) bool {
+	return strings.HasSuffix(r.URL.EscapedPath(), restoreSuffix)
+}
@@ -0,0 +1,151 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"expvar"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/elastic/go-elasticsearch/v8/esapi"
+)
+
+type cacheStats struct {
+	HitCount  int64 `json:"hit_count"`
+	MissCount int64 `json:"miss_count"`
+}
+
+func (c cacheStats) hitRate() float64 {
+	total := c.HitCount + c.MissCount
+	if total == 0 {
+		return 0
+	}
+	return float64(c.HitCount) / float64(total)
+}
+
+type indexStats struct {
+	Indices      []string   `json:"indices"`
+	Documents    int64      `json:"documents"`
+	SizeBytes    int64      `json:"size_bytes"`
+	Segments     int64      `json:"segments"`
+	QueryCache   float64    `json:"query_cache_hit_rate"`
+	RequestCache float64    `json:"request_cache_hit_rate"`
+	LastReindex  *time.Time `json:"last_reindex_at,omitempty"`
+}
+
+// indexStats는 store의 별칭(로케일 인덱스)이 가리키는 인덱스들의 통계입니다.
+func (s *store) indexStats(ctx context.Context) (indexStats, error) {
+	res, err := esapi.IndicesStatsRequest{
+		Index:  []string{s.index},
+		Metric: []string{"docs", "store", "segments", "query_cache", "request_cache"},
+	}.Do(ctx, s.client)
+	if err != nil {
+		return indexStats{}, fmt.Errorf("인덱스 통계 요청 실패: %w", err)
+	}
+	defer discard(res.Body)
+	if res.IsError() {
+		return indexStats{}, fmt.Errorf("인덱스 통계 응답 에러: %s", res.String())
+	}
+
+	type section struct {
+		Docs struct {
+			Count int64 `json:"count"`
+		} `json:"docs"`
+		Store struct {
+			SizeInBytes int64 `json:"size_in_bytes"`
+		} `json:"store"`
+		Segments struct {
+			Count int64 `json:"count"`
+		} `json:"segments"`
+		QueryCache   cacheStats `json:"query_cache"`
+		RequestCache cacheStats `json:"request_cache"`
+	}
+	var parsed struct {
+		All struct {
+			Primaries section `json:"primaries"`
+			Total     section `json:"total"`
+		} `json:"_all"`
+		Indices map[string]json.RawMessage `json:"indices"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&parsed); err != nil {
+		return indexStats{}, fmt.Errorf("응답 파싱 실패: %w", err)
+	}
+
+	out := indexStats{
+		Documents:    parsed.All.Primaries.Docs.Count,
+		SizeBytes:    parsed.All.Total.Store.SizeInBytes,
+		Segments:     parsed.All.Total.Segments.Count,
+		QueryCache:   parsed.All.Total.QueryCache.hitRate(),
+		RequestCache: parsed.All.Total.RequestCache.hitRate(),
+	}
+	for name := range parsed.Indices {
+		out.Indices = append(out.Indices, name)
+		// 복원으로 만든 인덱스는 <별칭>-v<UTC 시각> 이름을 가집니다.
+		if v, ok := strings.CutPrefix(name, s.index+"-v"); ok {
+			if t, err := time.Parse(snapshotKeyLayout, strings.ToUpper(v)); err == nil {
+				if out.LastReindex == nil || t.After(*out.LastReindex) {
+					out.LastReindex = &t
+				}
+			}
+		}
+	}
+	return out, nil
+}
+
+// handleStats의 index, settings_drift는 기본 로케일 값이고, locales에는 로케일 이름별로 같은 항목이 들어갑니다.
+func (s *server) handleStats(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), s.cfg.AdminTimeout)
+	defer cancel()
+	idx, err := s.st.indexStats(ctx)
+	if err != nil {
+		logf(r.Context(), "인덱스 통계 조회 실패: %v", err)
+		writeError(w, r, codeSearchFailed)
+		return
+	}
+
+	internal := map[string]interface{}{}
+	for _, name := range []string{"bulk_flush_total", "bulk_write_failures_total", "suggest_failover_total", "es_semaphore_rejected_total"} {
+		if v := expvar.Get(name); v != nil {
+			internal[name] = json.RawMessage(v.String())
+		}
+	}
+	if s.writer != nil {
+		internal["ingestion"] = s.writer.stats()
+	}
+	internal["bulk_mode"] = s.bulk.status()
+	if s.mir != nil {
+		internal["mirror_dead_letters"] = len(s.mir.deadLetterSnapshot())
+	}
+	payload := map[string]interface{}{
+		"index":    idx,
+		"internal": internal,
+		"settings": s.st.settings,
+	}
+
+	locales := map[string]interface{}{}
+	for _, locale := range s.cfg.locales() {
+		st := s.st.withLocale(locale)
+		entry := map[string]interface{}{"index": idx, "settings": st.settings}
+		if locale != "" {
+			ls, err := st.indexStats(ctx)
+			if err != nil {
+				logf(r.Context(), "인덱스 통계 조회 실패 (%s): %v", st.index, err)
+				continue
+			}
+			entry["index"] = ls
+		}
+		if drift, err := st.settingsDrift(ctx, st.index); err != nil {
+			logf(r.Context(), "인덱스 설정 비교 실패 (%s): %v", st.index, err)
+		} else {
+			entry["settings_drift"] = drift
+			if locale == "" {
+				payload["settings_drift"] = drift
+			}
+		}
+		locales[s.cfg.localeName(locale)] = entry
+	}
+	payload["locales"] = locales
+	writeJSON(w, payload)
+}
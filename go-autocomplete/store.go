@@ -0,0 +1,525 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	elastic "github.com/elastic/go-elasticsearch/v8"
+	"github.com/elastic/go-elasticsearch/v8/esapi"
+	opensearch "github.com/opensearch-project/opensearch-go/v2"
+)
+
+const (
+	backendElasticsearch = "elasticsearch"
+	backendOpenSearch    = "opensearch"
+)
+
+// store는 검색 백엔드 접근을 한곳에 모읍니다. 요청은 esapi 구조체로 만들고
+// Elasticsearch/OpenSearch 클라이언트 모두가 구현하는 Perform으로 보냅니다.
+//
+// completion suggester 조회(contexts, skip_duplicates, fuzzy.unicode_aware, 옵션의 _source)와 인덱스 매핑은
+// OpenSearch 2.x가 갈라져 나온 ES 7.10 이전부터 같은 형식이라 백엔드별로 바꾸지 않습니다. backend는 형식이
+// 다른 곳(수명 주기 정책 ILM/ISM)에서만 읽고, ES 전용 기능(dense_vector/kNN)은 시작 시 막습니다.
+type store struct {
+	client   esapi.Transport
+	backend  string
+	index    string
+	settings indexSettings
+}
+
+func newStore(ctx context.Context, cfg config) (*store, error) {
+	var transport http.RoundTripper
+	username, password := cfg.ESUsername, cfg.ESPassword
+	if cfg.SigV4 {
+		// go-elasticsearch v8 클라이언트는 응답의 제품 헤더를 확인하므로 Amazon OpenSearch Service에 붙지 못합니다.
+		if cfg.Backend != backendOpenSearch {
+			return nil, fmt.Errorf("ELASTICSEARCH_SIGV4는 SEARCH_BACKEND=opensearch에서만 쓸 수 있음 (현재 %q, %s)", cfg.Backend, cfg.ESURL)
+		}
+		t, err := newSigV4Transport(ctx, cfg.SigV4Region, cfg.SigV4Service)
+		if err != nil {
+			return nil, fmt.Errorf("SigV4 초기화 실패: %w", err)
+		}
+		transport = t
+		username, password = "", ""
+	}
+
+	switch cfg.Backend {
+	case backendElasticsearch:
+		es, err := elastic.NewClient(elastic.Config{
+			Addresses: []string{cfg.ESURL},
+			Username:  username,
+			Password:  password,
+			Transport: transport,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("elasticsearch 초기화 실패: %w", err)
+		}
+		return newLimitedStore(es, cfg), nil
+	case backendOpenSearch:
+		osc, err := opensearch.NewClient(opensearch.Config{
+			Addresses: []string{cfg.ESURL},
+			Username:  username,
+			Password:  password,
+			Transport: transport,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("opensearch 초기화 실패: %w", err)
+		}
+		return newLimitedStore(osc, cfg), nil
+	default:
+		return nil, fmt.Errorf("알 수 없는 SEARCH_BACKEND: %q", cfg.Backend)
+	}
+}
+
+func newLimitedStore(client esapi.Transport, cfg config) *store {
+	client = opaqueIDTransport{next: client}
+	if cfg.MaxConcurrentReads > 0 && cfg.MaxConcurrentWrites > 0 {
+		client = newLimitedTransport(client, cfg.MaxConcurrentReads, cfg.MaxConcurrentWrites, cfg.ConcurrencyWait)
+	}
+	return &store{client: client, backend: cfg.Backend, index: indexName, settings: cfg.indexSettings()}
+}
+
+func (s *store) ensureIndex(ctx context.Context) error {
+	mapping, err := s.settings.render()
+	if err != nil {
+		return err
+	}
+	created, err := s.ensureNamedIndex(ctx, s.index, mapping)
+	if err != nil || created {
+		return err
+	}
+	drift, err := s.settingsDrift(ctx, s.index)
+	if err != nil {
+		log.Printf("인덱스 설정 비교 실패: %v", err)
+		return nil
+	}
+	for _, d := range drift {
+		log.Printf("인덱스 설정 불일치: %s (설정 %v, 실제 %v) — 재색인 전까지 적용되지 않음", d.Setting, d.Configured, d.Actual)
+	}
+	return nil
+}
+
+// ensureNamedIndex는 인덱스가 없으면 만들고, 새로 만들었는지 여부를 돌려줍니다.
+func (s *store) ensureNamedIndex(ctx context.Context, name, mapping string) (bool, error) {
+	res, err := esapi.IndicesExistsRequest{Index: []string{name}}.Do(ctx, s.client)
+	if err != nil {
+		return false, fmt.Errorf("인덱스 확인 실패: %w", err)
+	}
+	defer discard(res.Body)
+	if res.StatusCode == http.StatusOK {
+		return false, nil
+	}
+	if res.StatusCode != http.StatusNotFound {
+		return false, fmt.Errorf("인덱스 확인 응답 코드: %d", res.StatusCode)
+	}
+
+	if err := s.createIndex(ctx, name, mapping); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func (s *store) createIndex(ctx context.Context, name, mapping string) error {
+	createReq := esapi.IndicesCreateRequest{
+		Index: name,
+		Body:  strings.NewReader(mapping),
+	}
+	createRes, err := createReq.Do(ctx, s.client)
+	if err != nil {
+		return fmt.Errorf("인덱스 생성 실패: %w", err)
+	}
+	defer discard(createRes.Body)
+	if createRes.IsError() {
+		return fmt.Errorf("인덱스 생성 응답 에러: %s", createRes.String())
+	}
+	return nil
+}
+
+// upsertKeyword는 키워드를 업서트하고 새 문서를 만들었는지 여부를 돌려줍니다.
+func (s *store) upsertKeyword(ctx context.Context, req upsertRequest) (bool, error) {
+	keyword := canonicalKeyword(req.Keyword)
+	if keyword == "" {
+		return false, errors.New("keyword가 비어 있음")
+	}
+	if req.WeightDelta != 0 {
+		return s.incrementWeight(ctx, keyword, req)
+	}
+	if req.Weight == 0 {
+		req.Weight = 1
+	}
+	if req.Meta == nil {
+		req.Meta = map[string]interface{}{}
+	}
+
+	payload := map[string]interface{}{
+		"doc":    keywordDoc(keyword, req.Weight, req),
+		"upsert": s.newKeywordDoc(keyword, req.Weight, req),
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return false, fmt.Errorf("payload 직렬화 실패: %w", err)
+	}
+
+	retries := updateRetries
+	updateReq := esapi.UpdateRequest{
+		Index:           s.index,
+		DocumentID:      docID(keyword),
+		Body:            bytes.NewReader(body),
+		RetryOnConflict: &retries,
+	}
+	res, err := updateReq.Do(ctx, s.client)
+	if err != nil {
+		return false, fmt.Errorf("업서트 요청 실패: %w", err)
+	}
+	defer discard(res.Body)
+	if res.IsError() {
+		return false, fmt.Errorf("업서트 응답 에러: %s", res.String())
+	}
+	var parsed struct {
+		Result string `json:"result"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&parsed); err != nil {
+		return false, fmt.Errorf("응답 파싱 실패: %w", err)
+	}
+	return parsed.Result == "created", nil
+}
+
+func keywordDoc(keyword string, weight int, req upsertRequest) map[string]interface{} {
+	suggest := map[string]interface{}{
+		"input":  suggestInputs(keyword, req.Romanize != nil && *req.Romanize),
+		"weight": weight,
+	}
+	if len(req.Channels) > 0 {
+		suggest["contexts"] = map[string]interface{}{"channel": req.Channels}
+	}
+	doc := map[string]interface{}{
+		"keyword": matchKey(keyword),
+		"weight":  weight,
+		"suggest": suggest,
+	}
+	if req.Meta != nil {
+		doc["meta"] = req.Meta
+	}
+	if req.ExpiresAt != nil {
+		doc["expires_at"] = req.ExpiresAt
+	}
+	if display := canonicalKeyword(req.Display); display != "" {
+		doc["display"] = display
+	}
+	doc["last_seen_at"] = time.Now().UTC()
+	// 소프트 삭제된 키워드를 다시 업서트하면 되살립니다.
+	doc["deleted_at"] = nil
+	doc["tombstone_input"] = nil
+	return doc
+}
+
+// newKeywordDoc은 문서를 새로 만들 때 쓰는 본문입니다. display를 주지 않으면 처음 들어온 표기를 그대로 씁니다.
+// 기존 문서를 갱신할 때는 keywordDoc만 병합하므로 나중에 들어온 소문자 표기가 브랜드 표기를, 채널 없이 들어온
+// 업서트가 기존 채널 지정을 덮지 않습니다.
+func (s *store) newKeywordDoc(keyword string, weight int, req upsertRequest) map[string]interface{} {
+	if len(s.settings.Channels) > 0 && len(req.Channels) == 0 {
+		req.Channels = []string{channelAll}
+	}
+	doc := keywordDoc(keyword, weight, req)
+	if _, ok := doc["display"]; !ok {
+		doc["display"] = keyword
+	}
+	return doc
+}
+
+// suggest는 접두어로 completion suggester를 조회합니다. fuzziness가 있으면 오타를 허용하는 fuzzy 조회를 합니다.
+func (s *store) suggest(ctx context.Context, q, channel, fuzziness string) ([]scoredSuggestion, error) {
+	completion := map[string]interface{}{
+		"field":           "suggest",
+		"skip_duplicates": true,
+		// 만료 키워드를 거르고 표기 변형을 합친 뒤에도 suggestSize를 채울 수 있도록 여유 있게 요청합니다.
+		"size": suggestSize * 2,
+	}
+	if fuzziness != "" {
+		// 한글은 한 글자가 여러 바이트이므로 편집 거리를 문자 단위로 셉니다.
+		completion["fuzzy"] = map[string]interface{}{"fuzziness": fuzziness, "unicode_aware": true}
+	}
+	if len(s.settings.Channels) > 0 {
+		completion["contexts"] = map[string]interface{}{"channel": channelContexts(s.settings.Channels, channel)}
+	}
+	query := map[string]interface{}{
+		"suggest": map[string]interface{}{
+			"ac": map[string]interface{}{
+				"prefix":     q,
+				"completion": completion,
+			},
+		},
+		"_source": []string{"keyword", "expires_at", "deleted_at", "display", "meta", "last_seen_at", "click_score", "click_score_at"},
+	}
+	body, err := json.Marshal(query)
+	if err != nil {
+		return nil, fmt.Errorf("쿼리 직렬화 실패: %w", err)
+	}
+	searchReq := esapi.SearchRequest{
+		Index: []string{s.index},
+		Body:  bytes.NewReader(body),
+	}
+	res, err := searchReq.Do(ctx, s.client)
+	if err != nil {
+		return nil, fmt.Errorf("검색 요청 실패: %w", err)
+	}
+	defer discard(res.Body)
+	if res.IsError() {
+		return nil, fmt.Errorf("검색 응답 에러: %s", res.String())
+	}
+
+	var parsed struct {
+		Suggest map[string][]struct {
+			Options []struct {
+				Text   string  `json:"text"`
+				Score  float64 `json:"_score"`
+				Source struct {
+					Keyword   string                 `json:"keyword"`
+					ExpiresAt *time.Time             `json:"expires_at"`
+					DeletedAt *time.Time             `json:"deleted_at"`
+					Display   string                 `json:"display"`
+					Meta      map[string]interface{} `json:"meta"`
+
+					LastSeenAt   *time.Time `json:"last_seen_at"`
+					ClickScore   float64    `json:"click_score"`
+					ClickScoreAt int64      `json:"click_score_at"`
+				} `json:"_source"`
+			} `json:"options"`
+		} `json:"suggest"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&parsed); err != nil {
+		return nil, fmt.Errorf("응답 파싱 실패: %w", err)
+	}
+	now := time.Now()
+	var candidates []scoredSuggestion
+	for _, bucket := range parsed.Suggest["ac"] {
+		for _, opt := range bucket.Options {
+			if exp := opt.Source.ExpiresAt; (exp != nil && !now.Before(*exp)) || opt.Source.DeletedAt != nil {
+				continue
+			}
+			text := opt.Source.Display
+			if text == "" {
+				text = opt.Text
+			}
+			candidates = append(candidates, scoredSuggestion{
+				Text:         text,
+				Weight:       opt.Score,
+				Keyword:      opt.Source.Keyword,
+				Meta:         opt.Source.Meta,
+				LastSeenAt:   opt.Source.LastSeenAt,
+				ClickScore:   opt.Source.ClickScore,
+				ClickScoreAt: time.UnixMilli(opt.Source.ClickScoreAt),
+			})
+		}
+	}
+	// 순위 재조정(ranking)이 suggester 순서 밖의 후보도 올릴 수 있도록 합친 후보를 모두 돌려줍니다.
+	return dedupSuggestions(candidates, suggestSize*2), nil
+}
+
+type keywordWeight struct {
+	Keyword string
+	Weight  int
+}
+
+func (s *store) topKeywords(ctx context.Context, n int) ([]keywordWeight, error) {
+	const pageSize = 1000
+	var (
+		out         []keywordWeight
+		searchAfter []interface{}
+	)
+	for len(out) < n {
+		size := pageSize
+		if rest := n - len(out); rest < size {
+			size = rest
+		}
+		query := map[string]interface{}{
+			"size":    size,
+			"_source": []string{"keyword", "display", "weight"},
+			"query":   notExpiredQuery(),
+			"sort": []interface{}{
+				map[string]interface{}{"weight": map[string]interface{}{"order": "desc", "missing": "_last", "unmapped_type": "integer"}},
+				map[string]interface{}{"keyword": "asc"},
+			},
+		}
+		if searchAfter != nil {
+			query["search_after"] = searchAfter
+		}
+		body, err := json.Marshal(query)
+		if err != nil {
+			return nil, fmt.Errorf("쿼리 직렬화 실패: %w", err)
+		}
+		searchReq := esapi.SearchRequest{
+			Index: []string{s.index},
+			Body:  bytes.NewReader(body),
+		}
+		res, err := searchReq.Do(ctx, s.client)
+		if err != nil {
+			return nil, fmt.Errorf("상위 키워드 조회 실패: %w", err)
+		}
+		var parsed struct {
+			Hits struct {
+				Hits []struct {
+					Source struct {
+						Keyword string `json:"keyword"`
+						Display string `json:"display"`
+						Weight  int    `json:"weight"`
+					} `json:"_source"`
+					Sort []interface{} `json:"sort"`
+				} `json:"hits"`
+			} `json:"hits"`
+		}
+		if res.IsError() {
+			discard(res.Body)
+			return nil, fmt.Errorf("상위 키워드 응답 에러: %s", res.String())
+		}
+		err = json.NewDecoder(res.Body).Decode(&parsed)
+		discard(res.Body)
+		if err != nil {
+			return nil, fmt.Errorf("응답 파싱 실패: %w", err)
+		}
+
+		hits := parsed.Hits.Hits
+		for _, h := range hits {
+			keyword := h.Source.Display
+			if keyword == "" {
+				keyword = h.Source.Keyword
+			}
+			out = append(out, keywordWeight{Keyword: keyword, Weight: h.Source.Weight})
+		}
+		if len(hits) < size {
+			break
+		}
+		searchAfter = hits[len(hits)-1].Sort
+	}
+	return out, nil
+}
+
+// storedDoc은 scroll로 읽은 문서입니다. 스냅샷에는 어느 로케일 인덱스의 문서인지 Locale을 함께 씁니다.
+type storedDoc struct {
+	ID     string          `json:"_id"`
+	Locale string          `json:"locale,omitempty"`
+	Source json.RawMessage `json:"_source"`
+}
+
+// scan은 scroll API로 인덱스의 모든 문서를 순회합니다.
+func (s *store) scan(ctx context.Context, fn func(doc storedDoc) error) error {
+	const keepAlive = time.Minute
+	size := 1000
+	searchReq := esapi.SearchRequest{
+		Index:  []string{s.index},
+		Size:   &size,
+		Scroll: keepAlive,
+		Sort:   []string{"_doc"},
+	}
+	res, err := searchReq.Do(ctx, s.client)
+	if err != nil {
+		return fmt.Errorf("scroll 시작 실패: %w", err)
+	}
+
+	var scrollID string
+	defer func() {
+		if scrollID == "" {
+			return
+		}
+		clearRes, err := esapi.ClearScrollRequest{ScrollID: []string{scrollID}}.Do(context.Background(), s.client)
+		if err == nil {
+			discard(clearRes.Body)
+		}
+	}()
+
+	for {
+		if res.IsError() {
+			discard(res.Body)
+			return fmt.Errorf("scroll 응답 에러: %s", res.String())
+		}
+		var parsed struct {
+			ScrollID string `json:"_scroll_id"`
+			Hits     struct {
+				Hits []storedDoc `json:"hits"`
+			} `json:"hits"`
+		}
+		err := json.NewDecoder(res.Body).Decode(&parsed)
+		discard(res.Body)
+		if err != nil {
+			return fmt.Errorf("응답 파싱 실패: %w", err)
+		}
+		scrollID = parsed.ScrollID
+		if len(parsed.Hits.Hits) == 0 {
+			return nil
+		}
+		for _, doc := range parsed.Hits.Hits {
+			if err := fn(doc); err != nil {
+				return err
+			}
+		}
+
+		res, err = esapi.ScrollRequest{ScrollID: scrollID, Scroll: keepAlive}.Do(ctx, s.client)
+		if err != nil {
+			return fmt.Errorf("scroll 요청 실패: %w", err)
+		}
+	}
+}
+
+// indexMapping은 text/template으로 indexSettings 값을 채워 사용합니다.
+const indexMapping = `
+{
+  "settings": {
+    "number_of_shards": {{.Shards}},
+    "number_of_replicas": {{.Replicas}},
+    "analysis": {
+      "filter": {
+        "autocomplete_filter": {
+          "type": "edge_ngram",
+          "min_gram": {{.MinGram}},
+          "max_gram": {{.MaxGram}}
+        }
+      },
+      "analyzer": {
+        "autocomplete": {
+          "type": "custom",
+          "tokenizer": "{{.Tokenizer}}",
+          "filter": [
+            "lowercase",
+            "autocomplete_filter"
+          ]
+        }
+      }
+    }
+  },
+  "mappings": {
+    "_meta": {
+      "index_settings": {{.JSON}}
+    },
+    "properties": {
+      "keyword": { "type": "keyword" },
+      "display": { "type": "keyword", "index": false },
+      "weight": { "type": "integer" },
+      "expires_at": { "type": "date" },
+      "last_seen_at": { "type": "date" },
+      "last_clicked_at": { "type": "date" },
+      "click_score": { "type": "double" },
+      "click_score_at": { "type": "long" },
+      "deleted_at": { "type": "date" },
+      "tombstone_input": { "type": "keyword", "index": false },
+      {{- if .EmbeddingDims}}
+      "embedding": { "type": "dense_vector", "dims": {{.EmbeddingDims}}, "index": true, "similarity": "cosine" },
+      {{- end}}
+      "suggest": {
+        "type": "completion",
+        "analyzer": "autocomplete",
+        {{- if .Channels}}
+        "contexts": [{ "name": "channel", "type": "category" }],
+        {{- end}}
+        "preserve_separators": true
+      },
+      "meta": { "type": "object", "enabled": true }
+    }
+  }
+}`
@@ -0,0 +1,202 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/elastic/go-elasticsearch/v8/esapi"
+)
+
+const (
+	warmWorkers = 4
+	warmMaxTopN = 10000
+)
+
+type warmPrefix struct {
+	Prefix  string
+	Locale  string
+	Channel string
+}
+
+// topPrefixes는 쿼리 로그에서 since 이후 가장 많이 요청된 접두어 n개를 고릅니다. 접두어마다 가장 많이 쓰인
+// 로케일과 채널 조합 하나로 데웁니다.
+func (s *store) topPrefixes(ctx context.Context, since time.Time, n int) ([]warmPrefix, error) {
+	body, err := json.Marshal(map[string]interface{}{
+		"size":  0,
+		"query": map[string]interface{}{"range": map[string]interface{}{"@timestamp": map[string]interface{}{"gte": since.UTC().Format(time.RFC3339)}}},
+		"aggs": map[string]interface{}{
+			"prefixes": map[string]interface{}{
+				"terms": map[string]interface{}{"field": "prefix", "size": n},
+				"aggs": map[string]interface{}{
+					"locale":  map[string]interface{}{"terms": map[string]interface{}{"field": "locale", "size": 1}},
+					"channel": map[string]interface{}{"terms": map[string]interface{}{"field": "channel", "size": 1, "missing": ""}},
+				},
+			},
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("쿼리 직렬화 실패: %w", err)
+	}
+	res, err := esapi.SearchRequest{Index: []string{queryLogAlias}, Body: bytes.NewReader(body)}.Do(ctx, s.client)
+	if err != nil {
+		return nil, fmt.Errorf("검색 요청 실패: %w", err)
+	}
+	defer discard(res.Body)
+	if res.IsError() {
+		return nil, fmt.Errorf("검색 응답 에러: %s", res.String())
+	}
+
+	type terms struct {
+		Buckets []struct {
+			Key string `json:"key"`
+		} `json:"buckets"`
+	}
+	var parsed struct {
+		Aggregations struct {
+			Prefixes struct {
+				Buckets []struct {
+					Key     string `json:"key"`
+					Locale  terms  `json:"locale"`
+					Channel terms  `json:"channel"`
+				} `json:"buckets"`
+			} `json:"prefixes"`
+		} `json:"aggregations"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&parsed); err != nil {
+		return nil, fmt.Errorf("응답 파싱 실패: %w", err)
+	}
+	out := make([]warmPrefix, 0, len(parsed.Aggregations.Prefixes.Buckets))
+	for _, b := range parsed.Aggregations.Prefixes.Buckets {
+		p := warmPrefix{Prefix: b.Key}
+		if len(b.Locale.Buckets) > 0 {
+			p.Locale = b.Locale.Buckets[0].Key
+		}
+		if len(b.Channel.Buckets) > 0 {
+			p.Channel = b.Channel.Buckets[0].Key
+		}
+		out = append(out, p)
+	}
+	return out, nil
+}
+
+// warm은 쿼리 로그 상위 접두어의 suggest 결과를 캐시에 채웁니다. 설정에서 빠진 로케일·채널의 기록은 건너뜁니다.
+// jobID가 있으면 진행률을 기록합니다.
+func (s *server) warm(ctx context.Context, topN int, jobID string) (map[string]interface{}, error) {
+	prefixes, err := s.st.topPrefixes(ctx, time.Now().Add(-s.cfg.WarmWindow), topN)
+	if err != nil {
+		return nil, err
+	}
+
+	work := make(chan warmPrefix)
+	var (
+		wg                   sync.WaitGroup
+		done, warmed, failed int64
+		skipped              int
+	)
+	for i := 0; i < warmWorkers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for p := range work {
+				qctx, cancel := context.WithTimeout(ctx, s.live().SuggestTimeout)
+				_, _, err := s.suggestPrefix(qctx, p.Locale, p.Channel, p.Prefix)
+				cancel()
+				if err != nil {
+					atomic.AddInt64(&failed, 1)
+				} else {
+					atomic.AddInt64(&warmed, 1)
+				}
+				if n := atomic.AddInt64(&done, 1); jobID != "" && n%100 == 0 {
+					s.jobs.progress(jobID, int(n), float64(n)/float64(len(prefixes)))
+				}
+			}
+		}()
+	}
+	for _, p := range prefixes {
+		locale, ok := s.cfg.localeFromName(p.Locale)
+		if !ok || (p.Channel != "" && !knownChannel(s.cfg.Channels, p.Channel)) {
+			skipped++
+			continue
+		}
+		p.Locale = locale
+		select {
+		case work <- p:
+		case <-ctx.Done():
+		}
+		if ctx.Err() != nil {
+			break
+		}
+	}
+	close(work)
+	wg.Wait()
+
+	result := map[string]interface{}{
+		"prefixes":   len(prefixes),
+		"warmed":     warmed,
+		"failed":     failed,
+		"skipped":    skipped,
+		"cache_size": s.cache.size(),
+	}
+	return result, ctx.Err()
+}
+
+// warmOnStart는 시작 직후 캐시를 데운 뒤 준비 상태로 바꿉니다. 실패하거나 시간이 초과돼도 준비 상태로 바꿔
+// 캐시 없이 서비스합니다.
+func (s *server) warmOnStart(ctx context.Context, topN int, timeout time.Duration) {
+	defer s.ready.Store(true)
+	start := time.Now()
+	wctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	result, err := s.warm(wctx, topN, "")
+	if err != nil {
+		log.Printf("시작 캐시 데우기 실패 (%s): %v", time.Since(start).Round(time.Millisecond), err)
+		return
+	}
+	log.Printf("시작 캐시 데우기 완료 (%s): %v", time.Since(start).Round(time.Millisecond), result)
+}
+
+func (s *server) handleWarm(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		methodNotAllowed(w, r, http.MethodPost)
+		return
+	}
+	topN := s.cfg.WarmTopN
+	if raw := r.URL.Query().Get("top"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n < 1 || n > warmMaxTopN {
+			invalidParameter(w, r, "top")
+			return
+		}
+		topN = n
+	}
+	id := s.jobs.start("warm")
+	go func() {
+		ctx, cancel := context.WithTimeout(s.bg, s.cfg.AdminTimeout)
+		defer cancel()
+		result, err := s.warm(ctx, topN, id)
+		if err != nil {
+			logf(r.Context(), "캐시 데우기 실패: %v", err)
+		}
+		s.jobs.finish(id, result, err)
+	}()
+	writeJSONStatus(w, http.StatusAccepted, map[string]interface{}{"job_id": id, "status_url": "/admin/jobs/" + id})
+}
+
+// handleReady는 시작 캐시 데우기가 끝나기 전까지 503을 돌려줍니다. /healthz는 프로세스 생존만 봅니다.
+func (s *server) handleReady(w http.ResponseWriter, r *http.Request) {
+	if !s.ready.Load() {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		_, _ = w.Write([]byte("warming"))
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write([]byte("ok"))
+}
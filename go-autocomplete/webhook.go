@@ -0,0 +1,152 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"expvar"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	eventKeywordCreated  = "keyword.created"
+	eventKeywordUpdated  = "keyword.updated"
+	eventKeywordsDeleted = "keywords.deleted"
+
+	webhookSignatureHeader = "X-Webhook-Signature"
+	webhookTimestampHeader = "X-Webhook-Timestamp"
+	webhookEventHeader     = "X-Webhook-Event"
+
+	// 이벤트 하나에 싣는 키워드 목록의 최대 길이입니다. 넘치면 잘리고 Count로 전체 건수를 알 수 있습니다.
+	webhookMaxKeywords = 1000
+)
+
+var (
+	webhookDelivered = expvar.NewInt("webhook_delivered_total")
+	webhookFailures  = expvar.NewInt("webhook_failures_total")
+)
+
+// webhookEvent는 키워드 변경 알림 본문입니다. 여러 건이 한 번에 지워지는 정리 작업은 키워드 목록 대신
+// 건수와 사유(admin/gc/expired)를 보냅니다. 키워드를 직접 지정한 일괄 삭제(batch)는 Keywords도 채웁니다.
+type webhookEvent struct {
+	ID         string    `json:"id"`
+	Type       string    `json:"type"`
+	Keyword    string    `json:"keyword,omitempty"`
+	Locale     string    `json:"locale,omitempty"`
+	Reason     string    `json:"reason,omitempty"`
+	Prefix     string    `json:"prefix,omitempty"`
+	Count      int       `json:"count,omitempty"`
+	Keywords   []string  `json:"keywords,omitempty"`
+	OccurredAt time.Time `json:"occurred_at"`
+}
+
+// notifier는 키워드 변경을 설정된 URL들로 비동기 전송합니다. 요청 처리 경로를 막지 않도록 큐에 넣고,
+// 큐가 가득 차면 버리고 로그를 남깁니다. nil이면 아무것도 하지 않습니다.
+type notifier struct {
+	urls    []string
+	secret  []byte
+	queue   chan webhookEvent
+	client  *http.Client
+	retries int
+}
+
+func newNotifier(urls []string, secret string, queueSize, retries int, timeout time.Duration) *notifier {
+	return &notifier{
+		urls:    urls,
+		secret:  []byte(secret),
+		queue:   make(chan webhookEvent, queueSize),
+		client:  &http.Client{Timeout: timeout},
+		retries: retries,
+	}
+}
+
+func (n *notifier) publish(ev webhookEvent) {
+	if n == nil {
+		return
+	}
+	ev.ID = newRequestID()
+	ev.OccurredAt = time.Now().UTC()
+	select {
+	case n.queue <- ev:
+	default:
+		webhookFailures.Add(1)
+		log.Printf("웹훅 큐가 가득 차 이벤트 폐기: %s %s", ev.Type, ev.Keyword)
+	}
+}
+
+func (n *notifier) run(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case ev := <-n.queue:
+			body, err := json.Marshal(ev)
+			if err != nil {
+				log.Printf("웹훅 직렬화 실패: %v", err)
+				continue
+			}
+			for _, url := range n.urls {
+				if err := n.deliver(ctx, url, ev.Type, body); err != nil {
+					webhookFailures.Add(1)
+					log.Printf("웹훅 전송 실패 (%s %s): %v", ev.Type, url, err)
+					continue
+				}
+				webhookDelivered.Add(1)
+			}
+		}
+	}
+}
+
+// deliver는 2xx 응답을 받을 때까지 지수 백오프로 재시도합니다.
+func (n *notifier) deliver(ctx context.Context, url, eventType string, body []byte) error {
+	var lastErr error
+	for attempt := 0; attempt <= n.retries; attempt++ {
+		if attempt > 0 {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(time.Duration(1<<(attempt-1)) * time.Second):
+			}
+		}
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+		if err != nil {
+			return fmt.Errorf("요청 생성 실패: %w", err)
+		}
+		ts := strconv.FormatInt(time.Now().Unix(), 10)
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set(webhookEventHeader, eventType)
+		req.Header.Set(webhookTimestampHeader, ts)
+		req.Header.Set(webhookSignatureHeader, "sha256="+n.sign(ts, body))
+
+		res, err := n.client.Do(req)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		discard(res.Body)
+		if res.StatusCode >= 200 && res.StatusCode < 300 {
+			return nil
+		}
+		lastErr = fmt.Errorf("응답 코드 %d", res.StatusCode)
+		if res.StatusCode >= 400 && res.StatusCode < 500 && res.StatusCode != http.StatusTooManyRequests {
+			return lastErr
+		}
+	}
+	return lastErr
+}
+
+// sign은 "<timestamp>.<body>"의 HMAC-SHA256입니다. 수신 측은 타임스탬프가 오래된 요청을 거부해 재전송 공격을 막습니다.
+func (n *notifier) sign(ts string, body []byte) string {
+	mac := hmac.New(sha256.New, n.secret)
+	mac.Write([]byte(ts))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}